@@ -0,0 +1,206 @@
+package counter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// TAR_BLOCK_SIZE is the size of a tar header, the magic is found inside it
+const TAR_BLOCK_SIZE = 512
+
+// ErrNotArchive is returned by CountArchive for files that are neither a tar
+// nor a zip archive
+var ErrNotArchive = errors.New("not an archive")
+
+// MemberCounts is the result of counting a single file inside of an archive.
+// Err is set when that file could not be counted, the rest of the archive is
+// still counted
+type MemberCounts struct {
+	Name   string
+	Counts Counts
+	Err    error
+}
+
+// isZip reports whether the header starts a zip archive, an empty archive
+// starts with its end of central directory record
+func isZip(header []byte) bool {
+	return bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06"))
+}
+
+// isTar reports whether the block is a POSIX or GNU tar header. Old tar
+// archives have no magic and are not recognized
+func isTar(block []byte) bool {
+	return len(block) >= TAR_BLOCK_SIZE && bytes.HasPrefix(block[257:], []byte("ustar"))
+}
+
+// CountArchive counts every regular file inside of a zip or tar archive, which
+// may be compressed in any format Decompress supports, such as a .tar.gz. The
+// members are returned in the order they are stored. A member that can't be
+// read is reported in its MemberCounts, failing to read the archive itself
+// returns the members counted up to that point along with the error.
+// ErrNotArchive is returned for any other file
+func CountArchive(ctx context.Context, filename string, opts FileOptions) ([]MemberCounts, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	members, err := countArchive(ctx, file, opts)
+
+	// errors from the file already hold its name
+	var pathErr *fs.PathError
+	if err != nil && err != ErrNotArchive && !errors.As(err, &pathErr) {
+		return members, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return members, err
+}
+
+func countArchive(ctx context.Context, file *os.File, opts FileOptions) ([]MemberCounts, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	n, err := file.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if isZip(header[:n]) {
+		return countZip(ctx, file, info.Size(), opts)
+	}
+
+	// nothing is reported until the file is known to be an archive, otherwise
+	// it's counted again as a plain file
+	raw := &countingReader{r: contextReader{ctx: ctx, r: file}}
+
+	decompressed, err := decompressReader(bufio.NewReader(raw))
+	if err != nil {
+		// a compressed file that can't be decompressed is not a readable archive
+		return nil, ErrNotArchive
+	}
+
+	r := bufio.NewReaderSize(decompressed, TAR_BLOCK_SIZE)
+
+	block, _ := r.Peek(TAR_BLOCK_SIZE)
+	if !isTar(block) {
+		return nil, ErrNotArchive
+	}
+
+	if opts.Progress != nil {
+		opts.Progress(int(raw.n))
+		raw.r = opts.withProgress(raw.r)
+	}
+
+	members, err := countTar(ctx, r, opts)
+	if err == nil {
+		reportRest(opts, info.Size(), raw.n)
+	}
+
+	return members, err
+}
+
+// reportRest reports the bytes of an archive that were not read once it's
+// counted, such as the padding at the end of a tar archive
+func reportRest(opts FileOptions, size int64, reported uint) {
+	if opts.Progress != nil && uint(size) > reported {
+		opts.Progress(int(uint(size) - reported))
+	}
+}
+
+// countMember counts a single file of an archive. Members are decompressed
+// too when opts.Decompress is set
+func countMember(ctx context.Context, r io.Reader, opts FileOptions) (Counts, error) {
+	if opts.Decompress {
+		return countDecompressed(ctx, r, opts.What, opts.Words)
+	}
+
+	return getCountsWith(ctx, r, opts.What, opts.Words)
+}
+
+func countTar(ctx context.Context, r io.Reader, opts FileOptions) ([]MemberCounts, error) {
+	members := []MemberCounts{}
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return members, err
+		}
+
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		counts, err := countMember(ctx, tr, opts)
+		if ctx.Err() != nil {
+			return members, ctx.Err()
+		}
+
+		members = append(members, MemberCounts{Name: header.Name, Counts: counts, Err: err})
+	}
+}
+
+func countZip(ctx context.Context, r io.ReaderAt, size int64, opts FileOptions) ([]MemberCounts, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []MemberCounts{}
+	reported := uint(0)
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return members, err
+		}
+
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		counts, err := countZipMember(ctx, f, opts)
+		if ctx.Err() != nil {
+			return members, ctx.Err()
+		}
+
+		members = append(members, MemberCounts{Name: f.Name, Counts: counts, Err: err})
+
+		if opts.Progress != nil {
+			opts.Progress(int(f.CompressedSize64))
+			reported += uint(f.CompressedSize64)
+		}
+	}
+
+	reportRest(opts, size, reported)
+
+	return members, nil
+}
+
+func countZipMember(ctx context.Context, f *zip.File, opts FileOptions) (Counts, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return Counts{}, err
+	}
+	defer rc.Close()
+
+	return countMember(ctx, rc, opts)
+}
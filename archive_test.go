@@ -0,0 +1,255 @@
+package counter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+var archiveFiles = []struct {
+	name    string
+	content string
+}{
+	{name: "words.txt", content: "one two three\nfour five\n"},
+	{name: "empty.txt", content: ""},
+	{name: "nested/日本語.txt", content: "日本語 テキスト\n"},
+}
+
+// archiveMembers returns the counts every archive built from archiveFiles is
+// expected to produce
+func archiveMembers() []MemberCounts {
+	members := []MemberCounts{}
+
+	for _, file := range archiveFiles {
+		members = append(members, MemberCounts{Name: file.name, Counts: GetCounts(strings.NewReader(file.content))})
+	}
+
+	return members
+}
+
+// writeTar writes archiveFiles as a tar archive, along with a directory and a
+// symbolic link that are not counted
+func writeTar(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	tw := tar.NewWriter(w)
+
+	if err := tw.WriteHeader(&tar.Header{Name: "nested/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal("failed to write tar:", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "words.txt"}); err != nil {
+		t.Fatal("failed to write tar:", err)
+	}
+
+	for _, file := range archiveFiles {
+		header := &tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(file.content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal("failed to write tar:", err)
+		}
+		if _, err := tw.Write([]byte(file.content)); err != nil {
+			t.Fatal("failed to write tar:", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal("failed to write tar:", err)
+	}
+}
+
+// writeZip writes archiveFiles as a zip archive, along with a directory that
+// is not counted
+func writeZip(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	zw := zip.NewWriter(w)
+
+	if _, err := zw.Create("nested/"); err != nil {
+		t.Fatal("failed to write zip:", err)
+	}
+
+	for _, file := range archiveFiles {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			t.Fatal("failed to write zip:", err)
+		}
+		if _, err := fw.Write([]byte(file.content)); err != nil {
+			t.Fatal("failed to write zip:", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal("failed to write zip:", err)
+	}
+}
+
+func TestCountArchive(t *testing.T) {
+	dname := t.TempDir()
+
+	tarData := bytes.Buffer{}
+	writeTar(t, &tarData)
+
+	gzipped := bytes.Buffer{}
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(tarData.Bytes())
+	gw.Close()
+
+	zipData := bytes.Buffer{}
+	writeZip(t, &zipData)
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "archive.tar", data: tarData.Bytes()},
+		{name: "archive.tar.gz", data: gzipped.Bytes()},
+		{name: "archive.zip", data: zipData.Bytes()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(dname, tc.name)
+			if err := os.WriteFile(filename, tc.data, 0o644); err != nil {
+				t.Fatal("failed to create file:", err)
+			}
+
+			reported := 0
+			opts := FileOptions{Progress: func(n int) { reported += n }}
+
+			got, err := CountArchive(context.Background(), filename, opts)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, archiveMembers(), got)
+			assert.Equal(t, len(tc.data), reported, "progress")
+		})
+	}
+}
+
+func TestCountArchiveNotArchive(t *testing.T) {
+	dname := t.TempDir()
+
+	gzipped := bytes.Buffer{}
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte("one two three\n"))
+	gw.Close()
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "text", data: []byte("one two three\n")},
+		{name: "long text", data: []byte(strings.Repeat("one two three\n", 10000))},
+		{name: "gzip", data: gzipped.Bytes()},
+		{name: "corrupt gzip", data: []byte{0x1f, 0x8b, 0x00}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(dname, tc.name)
+			if err := os.WriteFile(filename, tc.data, 0o644); err != nil {
+				t.Fatal("failed to create file:", err)
+			}
+
+			reported := 0
+			opts := FileOptions{Progress: func(n int) { reported += n }}
+
+			got, err := CountArchive(context.Background(), filename, opts)
+			assert.Equal(t, ErrNotArchive, err)
+			assert.Equal(t, 0, len(got))
+			assert.Equal(t, 0, reported, "progress")
+
+			// the file is then counted as any other, reporting it once
+			_, err = CountFileWith(context.Background(), filename, opts)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, len(tc.data), reported, "progress")
+		})
+	}
+}
+
+func TestCountArchiveTruncatedTar(t *testing.T) {
+	tarData := bytes.Buffer{}
+	writeTar(t, &tarData)
+
+	// cut in the middle of the last file, the archive ends with its data block
+	// and two empty blocks
+	data := tarData.Bytes()[:tarData.Len()-3*TAR_BLOCK_SIZE+4]
+
+	filename := filepath.Join(t.TempDir(), "truncated.tar")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	got, err := CountArchive(context.Background(), filename, FileOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), filename+": ") {
+		t.Fatalf("expected an error holding the filename, got %v", err)
+	}
+
+	wants := archiveMembers()[:2]
+	if len(got) != len(wants)+1 {
+		t.Fatalf("expected %d members, got %d", len(wants)+1, len(got))
+	}
+
+	assert.Equal(t, wants, got[:2])
+	assert.Equal(t, io.ErrUnexpectedEOF, got[2].Err)
+}
+
+func TestCountArchiveZipMemberError(t *testing.T) {
+	zipData := bytes.Buffer{}
+	zw := zip.NewWriter(&zipData)
+
+	// members compressed with an unknown method can't be read
+	fw, err := zw.CreateRaw(&zip.FileHeader{Name: "unknown.bin", Method: 99})
+	if err != nil {
+		t.Fatal("failed to write zip:", err)
+	}
+	fw.Write([]byte("data"))
+
+	fw, err = zw.Create("words.txt")
+	if err != nil {
+		t.Fatal("failed to write zip:", err)
+	}
+	fw.Write([]byte("one two\n"))
+	zw.Close()
+
+	filename := filepath.Join(t.TempDir(), "archive.zip")
+	if err := os.WriteFile(filename, zipData.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	got, err := CountArchive(context.Background(), filename, FileOptions{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(got))
+
+	if !errors.Is(got[0].Err, zip.ErrAlgorithm) {
+		t.Errorf("expected %v, got %v", zip.ErrAlgorithm, got[0].Err)
+	}
+
+	assert.Equal(t, MemberCounts{Name: "words.txt", Counts: GetCounts(strings.NewReader("one two\n"))}, got[1])
+}
+
+func TestCountArchiveCancelled(t *testing.T) {
+	tarData := bytes.Buffer{}
+	writeTar(t, &tarData)
+
+	filename := filepath.Join(t.TempDir(), "archive.tar")
+	if err := os.WriteFile(filename, tarData.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CountArchive(ctx, filename, FileOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
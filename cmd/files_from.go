@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+const STDIN_NAME = "-"
+
+// ReadFilenames reads the list of filenames stored in the file called name,
+// or stdin when name is "-", separated by sep. The separator after the last
+// filename is optional. Empty filenames are reported as errors and skipped,
+// the last error is returned when the list could not be read
+func ReadFilenames(name string, sep byte) ([]string, []error, error) {
+	var r io.Reader = os.Stdin
+
+	if name != STDIN_NAME {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	return parseFilenames(r, name, sep)
+}
+
+func parseFilenames(r io.Reader, name string, sep byte) ([]string, []error, error) {
+	filenames := []string{}
+	errs := []error{}
+
+	reader := bufio.NewReader(r)
+
+	for i := 1; ; i++ {
+		filename, err := reader.ReadString(sep)
+
+		if err != nil && err != io.EOF {
+			return filenames, errs, fmt.Errorf("%s: %w", name, err)
+		}
+
+		// the input may or may not end with a separator
+		if err == io.EOF && filename == "" {
+			break
+		}
+
+		if len(filename) > 0 && filename[len(filename)-1] == sep {
+			filename = filename[:len(filename)-1]
+		}
+
+		if filename == "" {
+			errs = append(errs, fmt.Errorf("%s:%d: invalid zero-length file name", name, i))
+		} else {
+			filenames = append(filenames, filename)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return filenames, errs, nil
+}
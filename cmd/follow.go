@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	counter "bloom.io/github.com/FerDev12/wc-go"
+	"bloom.io/github.com/FerDev12/wc-go/display"
+)
+
+// FOLLOW_INTERVAL is how often -f checks the file for more data
+const FOLLOW_INTERVAL = counter.DEFAULT_FOLLOW_INTERVAL
+
+// FOLLOW_COLUMN_WIDTH fits counts of up to 10 digits and the padding
+const FOLLOW_COLUMN_WIDTH = 10 + display.PADDING
+
+// FOLLOW_UNSUPPORTED_FLAGS are the flags -f can't be combined with: a single
+// plain file is followed and its stats are never printed
+var FOLLOW_UNSUPPORTED_FLAGS = []string{
+	"z", "decompress", "archive", "stats", "progress",
+	"r", "gitignore", "include", "exclude", "files0-from", "files-from",
+}
+
+// checkFollow stops with an error when -f is combined with options it doesn't
+// support. The counts are printed as they change, which only the table does,
+// and words are separated by white space
+func checkFollow(format, wordRule, wordRegex string) {
+	if format != display.DEFAULT_FORMAT {
+		log.Fatalf("wc-go: -f only supports the %s format", display.DEFAULT_FORMAT)
+	}
+
+	if wordRule != DEFAULT_WORD_RULE || wordRegex != "" {
+		log.Fatalf("wc-go: -f cannot be used with -word-rule or -word-regex")
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(FOLLOW_UNSUPPORTED_FLAGS, f.Name) {
+			log.Fatalf("wc-go: -f cannot be used with -%s", f.Name)
+		}
+	})
+}
+
+// followFile prints a row with the counts of the single file in args every
+// time they change, until interrupted. It returns the exit status
+func followFile(ctx context.Context, args []string, what counter.CountOptions, opts display.Options) int {
+	if len(args) != 1 {
+		log.Fatalf("wc-go: -f takes a single file, got %d", len(args))
+	}
+	filename := args[0]
+
+	// every row is flushed as soon as it's printed, so the columns are given a
+	// fixed width wide enough for the header and large counts
+	width := FOLLOW_COLUMN_WIDTH
+	for _, column := range opts.Columns() {
+		width = max(width, len(column)+display.PADDING)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, width, display.TAB_WIDTH, display.PADDING, display.PAD_CHAR, display.TAB_FLAG)
+	opts.PrintHeader(w)
+
+	_, err := counter.Follow(ctx, filename, counter.FollowOptions{
+		// the stats are never printed
+		What:     what &^ counter.COUNT_STATS,
+		Interval: FOLLOW_INTERVAL,
+		OnUpdate: func(counts counter.Counts) {
+			opts.PrintRow(w, counts.Values(), filename)
+			w.Flush()
+		},
+	})
+
+	if ctx.Err() != nil {
+		return EXIT_INTERRUPTED
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "wc-go:", err)
+		return 1
+	}
+
+	return 0
+}
@@ -1,106 +1,429 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"sync"
-	"text/tabwriter"
+	"os/signal"
+	"runtime"
+	"strings"
 
 	counter "bloom.io/github.com/FerDev12/wc-go"
 	"bloom.io/github.com/FerDev12/wc-go/display"
 )
 
-const TAB_WIDTH = 8
-const PADDING = 4
-const PAD_CHAR = ' '
-const TAB_FLAG = tabwriter.AlignRight
+// EXIT_INTERRUPTED is the status shells use for processes killed by SIGINT
+const EXIT_INTERRUPTED = 130
+
+// stderr is where the errors found while counting are reported. It's replaced
+// by the progress line when it's shown, so the errors don't get mixed with it
+var stderr io.Writer = os.Stderr
 
 type FilesCountResult struct {
 	counts   counter.Counts
 	filename string
 	err      error
 	idx      int
+	// isArchive is set when the file was counted as an archive, members holds
+	// the counts of the files inside of it
+	isArchive bool
+	members   []counter.MemberCounts
 }
 
 func main() {
 	log.SetFlags(0)
 
 	displayOptionsArgs := display.NewOptionsArgs{}
+	walkOptions := WalkOptions{}
+	format := display.DEFAULT_FORMAT
+	workers := runtime.NumCPU()
+	fileOptions := counter.FileOptions{}
+	files0From := ""
+	filesFrom := ""
+	archives := false
+	wordRule := DEFAULT_WORD_RULE
+	wordRegex := ""
+	showProgress := false
+	follow := false
 
 	flag.BoolVar(&displayOptionsArgs.ShowLines, "l", false, "Used to toggle whether or not to show the line count")
 	flag.BoolVar(&displayOptionsArgs.ShowWords, "w", false, "Used to toggle whether or not to show the word count")
+	flag.BoolVar(&displayOptionsArgs.ShowChars, "m", false, "Used to toggle whether or not to show the character count")
 	flag.BoolVar(&displayOptionsArgs.ShowBytes, "c", false, "Used to toggle whether or not to show the byte count")
+	flag.BoolVar(&displayOptionsArgs.ShowMaxLineLength, "L", false, "Used to toggle whether or not to show the length of the longest line")
 	flag.BoolVar(&displayOptionsArgs.ShowHeader, "header", false, "Used to toggle whether or not to show the header")
+	flag.BoolVar(&displayOptionsArgs.ShowStats, "stats", false, "Show the min, max, mean, median, p95 and p99 of the length of the lines and of the words per line")
+
+	flag.StringVar(&format, "format", display.DEFAULT_FORMAT, "Output format, one of: "+strings.Join(display.Formatters(), ", "))
+
+	flag.BoolVar(&walkOptions.Recursive, "r", false, "Count every regular file inside of the given directories")
+	flag.BoolVar(&walkOptions.GitIgnore, "gitignore", false, "Skip the files ignored by .gitignore files when walking directories")
+	flag.Var(&walkOptions.Include, "include", "Only count files whose name matches the glob when walking directories, can be repeated")
+	flag.Var(&walkOptions.Exclude, "exclude", "Skip files and directories whose name matches the glob when walking directories, can be repeated")
+
+	flag.StringVar(&files0From, "files0-from", "", "Read the NUL separated names of the files to count from the file, - for stdin")
+	flag.StringVar(&filesFrom, "files-from", "", "Read the newline separated names of the files to count from the file, - for stdin")
+	flag.IntVar(&workers, "j", workers, "Number of files to count at the same time")
+	flag.Int64Var(&fileOptions.ParallelThreshold, "parallel-threshold", counter.DEFAULT_PARALLEL_THRESHOLD, "Size in bytes from which a file is split in chunks counted concurrently, 0 splits every file and a negative value disables it")
+	flag.BoolVar(&fileOptions.Mmap, "mmap", false, "Map regular files in memory instead of reading them, on Linux")
+	flag.IntVar(&fileOptions.Chunks, "chunks", runtime.NumCPU(), "Number of chunks large files are split in")
+	flag.BoolVar(&fileOptions.Decompress, "z", false, "Count the decompressed contents of gzip, bzip2, zlib and .Z files")
+	flag.BoolVar(&fileOptions.Decompress, "decompress", false, "Same as -z")
+	flag.BoolVar(&archives, "archive", false, "Count every file inside of tar, compressed tar and zip archives")
+	flag.StringVar(&wordRule, "word-rule", DEFAULT_WORD_RULE, "What a word is, one of: "+wordRuleNames())
+	flag.StringVar(&wordRegex, "word-regex", "", "Count every match of the regular expression as a word, the input is matched line by line")
+	flag.BoolVar(&follow, "f", false, "Keep counting the file as it grows and print the counts every time they change, like tail -f")
+	flag.BoolVar(&showProgress, "progress", false, "Show the progress on stderr while counting, only when stderr is a terminal")
 
 	flag.Parse()
 
+	if workers < 1 {
+		log.Fatalf("wc-go: -j must be at least 1, got %d", workers)
+	}
+
+	if fileOptions.Chunks < 1 {
+		log.Fatalf("wc-go: -chunks must be at least 1, got %d", fileOptions.Chunks)
+	}
+
+	// zero stands for the default in FileOptions, a threshold of one byte
+	// splits every file that isn't empty
+	if fileOptions.ParallelThreshold == 0 {
+		fileOptions.ParallelThreshold = 1
+	}
+
+	rule, err := NewWordRule(wordRule, wordRegex)
+	if err != nil {
+		log.Fatalf("wc-go: %s", err)
+	}
+	fileOptions.Words = rule
+
+	displayOptionsArgs.ShowCompressedBytes = fileOptions.Decompress
 	opts := display.NewOptions(displayOptionsArgs)
 
-	// instantiate tabwriter to provide tabular ouptut and define it's behaviour
-	wr := tabwriter.NewWriter(os.Stdout, 0, TAB_WIDTH, PADDING, PAD_CHAR, TAB_FLAG)
+	// the JSON output always holds every count
+	fileOptions.What = counter.COUNT_ALL
+	if format != "json" {
+		fileOptions.What = countOptions(opts)
+	}
+	if opts.ShouldShowStats() {
+		fileOptions.What |= counter.COUNT_STATS
+	}
 
-	filenames := flag.Args()
-	didError := false
-	totals := counter.Counts{}
+	formatter, err := display.NewFormatter(format, os.Stdout, opts)
+	if err != nil {
+		log.Fatalf("wc-go: %s", err)
+	}
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	if follow {
+		checkFollow(format, wordRule, wordRegex)
+		os.Exit(followFile(ctx, flag.Args(), fileOptions.What, opts))
+	}
+
+	args := flag.Args()
+	readStdin := len(args) == 0
+	inputErrs := []error{}
+
+	if files0From != "" || filesFrom != "" {
+		args, inputErrs = readFileList(args, files0From, filesFrom)
+		readStdin = false
+	}
+
+	filenames, walkErrs := ExpandFilenames(args, walkOptions)
+	inputErrs = append(inputErrs, walkErrs...)
+
+	for _, err := range inputErrs {
+		fmt.Fprintln(os.Stderr, "wc-go:", err)
+	}
+
+	var progress *Progress
+
+	if showProgress && isTerminal(os.Stderr) {
+		if readStdin {
+			progress = NewProgress(os.Stderr, 1, stdinSize())
+		} else {
+			progress = NewProgress(os.Stderr, len(filenames), totalSize(filenames))
+		}
+
+		fileOptions.Progress = progress.Add
+		stderr = progress
+		progress.Start()
+	}
+
+	var results <-chan FilesCountResult
+
+	if readStdin {
+		stdin := make(chan FilesCountResult, 1)
+		counts, err := counter.CountOpenFile(ctx, os.Stdin, fileOptions)
+		if progress != nil {
+			progress.FileDone()
+		}
+		stdin <- FilesCountResult{
+			counts: counts,
+			err:    err,
+		}
+		close(stdin)
+		results = stdin
+	} else {
+		var onDone func()
+		if progress != nil {
+			onDone = progress.FileDone
+		}
+		results = CountFiles(ctx, filenames, workers, fileOptions, archives, onDone)
+	}
+
+	if progress != nil {
+		results = progress.Track(results)
+	}
+
+	didError, err := PrintResults(formatter, results)
+	if err != nil {
+		fmt.Fprintln(stderr, "wc-go:", err)
+		os.Exit(1)
+	}
+
+	if ctx.Err() != nil {
+		os.Exit(EXIT_INTERRUPTED)
+	}
+
+	if didError || len(inputErrs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// countOptions selects the counts needed for the columns that are shown
+func countOptions(opts display.Options) counter.CountOptions {
+	what := counter.CountOptions(0)
+
+	if opts.ShouldShowLines() {
+		what |= counter.COUNT_LINES
+	}
+	if opts.ShouldShowWords() {
+		what |= counter.COUNT_WORDS
+	}
+	if opts.ShouldShowChars() {
+		what |= counter.COUNT_CHARS
+	}
+	if opts.ShouldShowBytes() {
+		what |= counter.COUNT_BYTES
+	}
+	if opts.ShouldShowMaxLineLength() {
+		what |= counter.COUNT_MAX_LINE_LENGTH
+	}
+
+	return what
+}
+
+// readFileList returns the filenames listed in the file given to either
+// -files0-from or -files-from. Listing the files is not compatible with
+// passing them as arguments
+func readFileList(args []string, files0From, filesFrom string) ([]string, []error) {
+	if files0From != "" && filesFrom != "" {
+		log.Fatalf("wc-go: -files0-from and -files-from cannot be used together")
+	}
+
+	name, sep, flagName := files0From, byte(0), "-files0-from"
+	if filesFrom != "" {
+		name, sep, flagName = filesFrom, '\n', "-files-from"
+	}
 
-	opts.PrintHeader(wr)
+	if len(args) > 0 {
+		log.Fatalf("wc-go: extra operand %q, file operands cannot be combined with %s", args[0], flagName)
+	}
+
+	filenames, errs, err := ReadFilenames(name, sep)
+	if err != nil {
+		log.Fatalf("wc-go: cannot read file names: %s", err)
+	}
 
-	ch := CountFiles(filenames)
+	return filenames, errs
+}
 
-	results := make([]FilesCountResult, len(filenames))
+// PrintResults renders every result through the formatter as it arrives and
+// reports the failed ones on stderr. It returns whether any file failed to be
+// counted
+func PrintResults(formatter display.Formatter, results <-chan FilesCountResult) (bool, error) {
+	didError := false
+	totals := counter.Counts{}
 
-	for res := range ch {
-		results[res.idx] = res
+	if err := formatter.Header(); err != nil {
+		return didError, err
 	}
 
-	for _, res := range results {
+	for res := range results {
+		if res.isArchive {
+			subtotal, failed, err := printArchive(formatter, res)
+			if err != nil {
+				return didError, err
+			}
+
+			didError = didError || failed
+			totals = totals.Add(subtotal)
+			continue
+		}
+
 		if res.err != nil {
 			didError = true
-			fmt.Fprintln(os.Stderr, "wc-go:", res.err)
+			fmt.Fprintln(stderr, "wc-go:", res.err)
+
+			if err := formatter.Error(res.filename, res.err); err != nil {
+				return didError, err
+			}
 			continue
 		}
+
 		totals = totals.Add(res.counts)
-		res.counts.Print(wr, opts, res.filename)
+
+		if err := formatter.Row(res.filename, res.counts.Values()); err != nil {
+			return didError, err
+		}
 	}
 
-	if len(filenames) == 0 {
-		counts := counter.GetCounts(os.Stdin)
-		counts.Print(wr, opts)
-	} else {
-		totals.Print(wr, opts, "total")
+	if err := formatter.Total(totals.Values()); err != nil {
+		return didError, err
 	}
 
-	wr.Flush()
+	return didError, formatter.Finish()
+}
 
-	if didError {
-		os.Exit(1)
+// printArchive renders a row per file inside of an archive, named after the
+// archive and the path inside of it, followed by the subtotal of the archive.
+// When the archive could only be read in part it is reported as failed
+// instead of its subtotal. It returns the subtotal and whether anything failed
+func printArchive(formatter display.Formatter, res FilesCountResult) (counter.Counts, bool, error) {
+	subtotal := counter.Counts{}
+	didError := false
+
+	for _, member := range res.members {
+		name := res.filename + ":" + member.Name
+
+		if member.Err != nil {
+			didError = true
+			err := fmt.Errorf("%s: %w", name, member.Err)
+			fmt.Fprintln(stderr, "wc-go:", err)
+
+			if err := formatter.Error(name, err); err != nil {
+				return subtotal, didError, err
+			}
+			continue
+		}
+
+		subtotal = subtotal.Add(member.Counts)
+
+		if err := formatter.Row(name, member.Counts.Values()); err != nil {
+			return subtotal, didError, err
+		}
+	}
+
+	if res.err != nil {
+		fmt.Fprintln(stderr, "wc-go:", res.err)
+		return subtotal, true, formatter.Error(res.filename, res.err)
 	}
+
+	return subtotal, didError, formatter.Row(res.filename, subtotal.Values())
 }
 
-func CountFiles(filenames []string) <-chan FilesCountResult {
-	ch := make(chan FilesCountResult)
+// countFile counts a single file. With archives set, tar and zip archives are
+// counted file by file and everything else as usual
+func countFile(ctx context.Context, filename string, opts counter.FileOptions, archives bool) FilesCountResult {
+	if archives {
+		members, err := counter.CountArchive(ctx, filename, opts)
+		if !errors.Is(err, counter.ErrNotArchive) {
+			return FilesCountResult{
+				filename:  filename,
+				err:       err,
+				isArchive: err == nil || members != nil,
+				members:   members,
+			}
+		}
+	}
+
+	counts, err := counter.CountFileWith(ctx, filename, opts)
+
+	return FilesCountResult{
+		filename: filename,
+		counts:   counts,
+		err:      err,
+	}
+}
+
+type countJob struct {
+	filename string
+	idx      int
+	result   chan<- FilesCountResult
+}
 
-	wg := sync.WaitGroup{}
-	wg.Add(len(filenames))
+// InterruptContext returns a context that is cancelled on the first SIGINT.
+// Files being counted stop at their next read and no more files are started.
+// Signals are handled normally again after the first one, so a second SIGINT
+// terminates the process even if it's blocked reading
+func InterruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 
-	for i, filename := range filenames {
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
+}
+
+// CountFiles counts the files using a pool of workers. Results are sent in
+// the same order as the filenames, each one as soon as it and every file
+// before it are done. At most workers files are open at the same time and
+// the results waiting for an earlier file to finish are bounded as well.
+// Once the context is cancelled no more files are started. With archives set
+// the files inside of archives are counted one by one. onDone, when not nil,
+// is called by the workers as soon as each file is counted, in any order
+func CountFiles(ctx context.Context, filenames []string, workers int, opts counter.FileOptions, archives bool, onDone func()) <-chan FilesCountResult {
+	workers = max(workers, 1)
+
+	ch := make(chan FilesCountResult)
+	jobs := make(chan countJob)
+	// pending holds the result channel of every dispatched file in order, its
+	// capacity bounds how far ahead of the output the workers can get
+	pending := make(chan chan FilesCountResult, workers)
+
+	for range workers {
 		go func() {
-			defer wg.Done()
-			counts, err := counter.CountFile(filename)
-			ch <- FilesCountResult{
-				filename: filename,
-				counts:   counts,
-				err:      err,
-				idx:      i,
+			for job := range jobs {
+				res := countFile(ctx, job.filename, opts, archives)
+				res.idx = job.idx
+				if onDone != nil {
+					onDone()
+				}
+				job.result <- res
 			}
 		}()
 	}
 
 	go func() {
-		wg.Wait()
-		close(ch)
+		defer close(jobs)
+		defer close(pending)
+
+		for i, filename := range filenames {
+			if ctx.Err() != nil {
+				return
+			}
+
+			result := make(chan FilesCountResult, 1)
+			pending <- result
+			jobs <- countJob{filename: filename, idx: i, result: result}
+		}
+	}()
+
+	go func() {
+		defer close(ch)
+
+		for result := range pending {
+			ch <- <-result
+		}
 	}()
 
 	return ch
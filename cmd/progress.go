@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PROGRESS_INTERVAL is how often the progress line is redrawn
+const PROGRESS_INTERVAL = 200 * time.Millisecond
+
+// CLEAR_LINE moves the cursor to the start of the line and erases it
+const CLEAR_LINE = "\r\x1b[K"
+
+// Progress keeps a status line up to date on a terminal while files are
+// counted: the files done out of the total, the bytes counted, the throughput
+// and the time left, estimated from the size of the files. Messages written
+// through it clear the line first, it's redrawn on the next tick
+type Progress struct {
+	w     io.Writer
+	files int
+	size  int64
+	start time.Time
+
+	done  atomic.Int64
+	bytes atomic.Int64
+
+	// mu guards the writes to w
+	mu       sync.Mutex
+	drawn    bool
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewProgress returns a progress line for counting files adding up to size
+// bytes, zero when it's not known
+func NewProgress(w io.Writer, files int, size int64) *Progress {
+	return &Progress{
+		w:       w,
+		files:   files,
+		size:    size,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// totalSize adds up the size of the regular files, the rest are left out
+func totalSize(filenames []string) int64 {
+	size := int64(0)
+
+	for _, filename := range filenames {
+		if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+
+	return size
+}
+
+// stdinSize returns the size left to read from stdin, zero when it's not a
+// regular file
+func stdinSize() int64 {
+	info, err := os.Stdin.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+
+	offset, err := os.Stdin.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+
+	return max(info.Size()-offset, 0)
+}
+
+// Add counts n more bytes, it's safe for concurrent use
+func (p *Progress) Add(n int) {
+	p.bytes.Add(int64(n))
+}
+
+// Start draws the line every PROGRESS_INTERVAL until Stop is called
+func (p *Progress) Start() {
+	p.start = time.Now()
+
+	go func() {
+		defer close(p.stopped)
+
+		ticker := time.NewTicker(PROGRESS_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				p.draw(now)
+			}
+		}
+	}()
+}
+
+// Stop stops drawing and clears the line
+func (p *Progress) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.stopped
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.clear()
+	})
+}
+
+// FileDone counts one more file done, it's safe for concurrent use
+func (p *Progress) FileDone() {
+	p.done.Add(1)
+}
+
+// Track forwards the results and stops the progress once there are no more,
+// before the rest of the output is printed
+func (p *Progress) Track(results <-chan FilesCountResult) <-chan FilesCountResult {
+	ch := make(chan FilesCountResult)
+
+	go func() {
+		defer close(ch)
+
+		for res := range results {
+			ch <- res
+		}
+
+		p.Stop()
+	}()
+
+	return ch
+}
+
+// Write clears the line and writes the message in its place
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+	return p.w.Write(b)
+}
+
+func (p *Progress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.w, CLEAR_LINE)
+		p.drawn = false
+	}
+}
+
+func (p *Progress) draw(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprint(p.w, CLEAR_LINE+p.line(now.Sub(p.start)))
+	p.drawn = true
+}
+
+// line describes the progress after counting for elapsed
+func (p *Progress) line(elapsed time.Duration) string {
+	bytes := p.bytes.Load()
+	rate := int64(0)
+	if elapsed > 0 {
+		rate = int64(float64(bytes) / elapsed.Seconds())
+	}
+
+	line := fmt.Sprintf("%d/%d files, %s", p.done.Load(), p.files, formatSize(bytes))
+	if p.size > 0 {
+		line += " / " + formatSize(p.size)
+	}
+	line += ", " + formatSize(rate) + "/s"
+
+	if p.size > 0 {
+		eta := "--"
+		if rate > 0 {
+			left := float64(max(p.size-bytes, 0)) / float64(rate)
+			eta = time.Duration(left * float64(time.Second)).Round(time.Second).String()
+		}
+		line += ", ETA " + eta
+	}
+
+	return line
+}
+
+// formatSize writes the bytes with a binary unit and a decimal
+func formatSize(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	value := float64(n) / unit
+	prefix := 0
+	for value >= unit && prefix < len("KMGTPE")-1 {
+		value /= unit
+		prefix++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[prefix])
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestProgressLine(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		files   int
+		size    int64
+		done    int
+		bytes   int
+		elapsed time.Duration
+		wants   string
+	}{
+		{
+			desc:    "known size",
+			files:   3,
+			size:    10 * 1024 * 1024,
+			done:    1,
+			bytes:   2 * 1024 * 1024,
+			elapsed: 2 * time.Second,
+			wants:   "1/3 files, 2.0 MiB / 10.0 MiB, 1.0 MiB/s, ETA 8s",
+		},
+		{
+			desc:    "eta rounded to seconds",
+			files:   1,
+			size:    10000,
+			bytes:   3000,
+			elapsed: time.Second,
+			wants:   "0/1 files, 2.9 KiB / 9.8 KiB, 2.9 KiB/s, ETA 2s",
+		},
+		{
+			desc:    "unknown size",
+			files:   2,
+			bytes:   1536,
+			elapsed: time.Second,
+			wants:   "0/2 files, 1.5 KiB, 1.5 KiB/s",
+		},
+		{
+			desc:  "nothing counted yet",
+			files: 1,
+			size:  1024,
+			wants: "0/1 files, 0 B / 1.0 KiB, 0 B/s, ETA --",
+		},
+		{
+			desc:    "more bytes than the size",
+			files:   1,
+			size:    1024,
+			done:    1,
+			bytes:   2048,
+			elapsed: time.Second,
+			wants:   "1/1 files, 2.0 KiB / 1.0 KiB, 2.0 KiB/s, ETA 0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := NewProgress(io.Discard, tc.files, tc.size)
+			for range tc.done {
+				p.FileDone()
+			}
+			p.Add(tc.bytes)
+
+			assert.Equal(t, tc.wants, p.line(tc.elapsed))
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		n     int64
+		wants string
+	}{
+		{n: 0, wants: "0 B"},
+		{n: 1023, wants: "1023 B"},
+		{n: 1024, wants: "1.0 KiB"},
+		{n: 1536, wants: "1.5 KiB"},
+		{n: 1024 * 1024, wants: "1.0 MiB"},
+		{n: 5 * 1024 * 1024 * 1024, wants: "5.0 GiB"},
+		{n: 3 * 1024 * 1024 * 1024 * 1024, wants: "3.0 TiB"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.wants, formatSize(tc.n), tc.wants)
+	}
+}
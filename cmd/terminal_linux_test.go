@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestIsTerminalDevNull(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal("failed to open the null device:", err)
+	}
+	defer devNull.Close()
+
+	// the null device is a character device but not a terminal
+	assert.Equal(t, false, isTerminal(devNull))
+}
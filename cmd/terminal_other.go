@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// isTerminal is only exact on Linux, everywhere else any character device is
+// taken for a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
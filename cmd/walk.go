@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bloom.io/github.com/FerDev12/wc-go/gitignore"
+)
+
+// GlobsFlag collects every value of a repeated glob flag
+type GlobsFlag []string
+
+func (g *GlobsFlag) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *GlobsFlag) Set(pattern string) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return err
+	}
+
+	*g = append(*g, pattern)
+	return nil
+}
+
+type WalkOptions struct {
+	Recursive bool
+	GitIgnore bool
+	Include   GlobsFlag
+	Exclude   GlobsFlag
+}
+
+// matchesAny reports whether the base name of path matches any of the
+// patterns
+func matchesAny(patterns []string, path string) bool {
+	name := filepath.Base(path)
+
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldCount reports whether a file found while walking a directory passes
+// the include and exclude filters
+func (opts WalkOptions) shouldCount(path string) bool {
+	if len(opts.Include) > 0 && !matchesAny(opts.Include, path) {
+		return false
+	}
+
+	return !matchesAny(opts.Exclude, path)
+}
+
+// ExpandFilenames replaces every directory in filenames with the regular files
+// found inside of it when walking recursively. Files given explicitly are
+// always kept, the include, exclude and .gitignore filters only apply to the
+// files found while walking. Excluded and ignored directories are not walked
+// into
+func ExpandFilenames(filenames []string, opts WalkOptions) ([]string, []error) {
+	if !opts.Recursive {
+		return filenames, nil
+	}
+
+	expanded := []string{}
+	errs := []error{}
+
+	for _, filename := range filenames {
+		info, err := os.Stat(filename)
+		if err != nil || !info.IsDir() {
+			// let the counter report the error in order with the other files
+			expanded = append(expanded, filename)
+			continue
+		}
+
+		ignore := gitignore.NewMatcher()
+
+		err = filepath.WalkDir(filename, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+
+			rel, err := filepath.Rel(filename, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if d.IsDir() {
+				if path == filename {
+					return opts.addGitIgnore(ignore, rel, path)
+				}
+				if matchesAny(opts.Exclude, path) || opts.isIgnored(ignore, rel, true) {
+					return filepath.SkipDir
+				}
+				return opts.addGitIgnore(ignore, rel, path)
+			}
+
+			if !isRegular(path, d) || !opts.shouldCount(path) || opts.isIgnored(ignore, rel, false) {
+				return nil
+			}
+
+			expanded = append(expanded, path)
+			return nil
+		})
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return expanded, errs
+}
+
+// isIgnored reports whether the path, relative to the walked directory, is
+// ignored by git. The .git directory itself is always ignored
+func (opts WalkOptions) isIgnored(ignore *gitignore.Matcher, rel string, isDir bool) bool {
+	if !opts.GitIgnore {
+		return false
+	}
+
+	if isDir && filepath.Base(rel) == ".git" {
+		return true
+	}
+
+	return ignore.Match(rel, isDir)
+}
+
+// addGitIgnore reads the .gitignore file of a directory about to be walked
+func (opts WalkOptions) addGitIgnore(ignore *gitignore.Matcher, rel string, dir string) error {
+	if !opts.GitIgnore {
+		return nil
+	}
+
+	return ignore.AddFile(rel, dir)
+}
+
+// isRegular reports whether the entry is a regular file or a symbolic link to
+// one
+func isRegular(path string, d fs.DirEntry) bool {
+	if d.Type().IsRegular() {
+		return true
+	}
+
+	if d.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	counter "bloom.io/github.com/FerDev12/wc-go"
+)
+
+const DEFAULT_WORD_RULE = "whitespace"
+
+var wordRules = map[string]counter.WordRule{
+	DEFAULT_WORD_RULE: counter.WhitespaceWords,
+	"unicode":         counter.UnicodeWords,
+}
+
+// wordRuleNames lists the rules that can be given to -word-rule
+func wordRuleNames() string {
+	return strings.Join(slices.Sorted(maps.Keys(wordRules)), ", ")
+}
+
+// NewWordRule returns the rule selected by -word-rule, or a rule matching the
+// expression given to -word-regex. Both flags cannot be used together
+func NewWordRule(name, expr string) (counter.WordRule, error) {
+	if expr != "" {
+		if name != DEFAULT_WORD_RULE {
+			return nil, fmt.Errorf("-word-rule and -word-regex cannot be used together")
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -word-regex: %w", err)
+		}
+
+		return counter.RegexWords(re), nil
+	}
+
+	rule, ok := wordRules[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown word rule %q, must be one of: %s", name, wordRuleNames())
+	}
+
+	return rule, nil
+}
@@ -2,58 +2,337 @@ package counter
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"strconv"
-	"strings"
 	"unicode"
 
 	"bloom.io/github.com/FerDev12/wc-go/display"
 )
 
+// Counts holds the result of counting an input. The counts are read through
+// its methods and built with NewCounts, the zero value is an empty input
 type Counts struct {
 	lines uint
 	words uint
+	chars uint
 	bytes uint
+	// maxLineLength is the display width of the longest line
+	maxLineLength uint
+	// compressedBytes is the size of the input before decompressing it, zero
+	// unless it was decompressed
+	compressedBytes uint
+	// stats is only collected with COUNT_STATS
+	stats *Stats
 }
 
+type NewCountsArgs struct {
+	Lines         uint
+	Words         uint
+	Chars         uint
+	Bytes         uint
+	MaxLineLength uint
+	// CompressedBytes is the size of the input before decompressing it
+	CompressedBytes uint
+	// Stats may be taken from other counts, nil when there are none
+	Stats *Stats
+}
+
+func NewCounts(args NewCountsArgs) Counts {
+	return Counts{
+		lines:           args.Lines,
+		words:           args.Words,
+		chars:           args.Chars,
+		bytes:           args.Bytes,
+		maxLineLength:   args.MaxLineLength,
+		compressedBytes: args.CompressedBytes,
+		stats:           args.Stats,
+	}
+}
+
+func (c Counts) Lines() uint {
+	return c.lines
+}
+
+func (c Counts) Words() uint {
+	return c.words
+}
+
+// Chars returns the number of UTF-8 encoded runes, invalid bytes are counted
+// as one character each
+func (c Counts) Chars() uint {
+	return c.chars
+}
+
+func (c Counts) Bytes() uint {
+	return c.bytes
+}
+
+// MaxLineLength returns the display width of the longest line
+func (c Counts) MaxLineLength() uint {
+	return c.maxLineLength
+}
+
+// CompressedBytes returns the size of the input before decompressing it, zero
+// unless it was decompressed
+func (c Counts) CompressedBytes() uint {
+	return c.compressedBytes
+}
+
+// CountOptions selects which counts are computed, as a combination of the
+// COUNT_* flags. Counts that are not selected are left at zero. The zero value
+// counts everything
+type CountOptions uint8
+
+const (
+	COUNT_LINES CountOptions = 1 << iota
+	COUNT_WORDS
+	COUNT_CHARS
+	COUNT_BYTES
+	COUNT_MAX_LINE_LENGTH
+	// COUNT_STATS collects the Stats of the lines, it's not part of COUNT_ALL
+	// and files are not split in chunks with it
+	COUNT_STATS
+
+	COUNT_ALL = COUNT_LINES | COUNT_WORDS | COUNT_CHARS | COUNT_BYTES | COUNT_MAX_LINE_LENGTH
+)
+
+func (what CountOptions) orAll() CountOptions {
+	if what == 0 {
+		return COUNT_ALL
+	}
+
+	return what
+}
+
+// needsDecoding reports whether the input has to be decoded as UTF-8, lines
+// and bytes can be counted without looking at the runes
+func (what CountOptions) needsDecoding() bool {
+	return what&(COUNT_WORDS|COUNT_CHARS|COUNT_MAX_LINE_LENGTH) != 0
+}
+
+// needsClasses reports whether every rune has to be classified, counting
+// characters only needs to find where runes start
+func (what CountOptions) needsClasses() bool {
+	return what&(COUNT_WORDS|COUNT_MAX_LINE_LENGTH) != 0
+}
+
+// only zeroes the counts that were not selected
+func (c Counts) only(what CountOptions) Counts {
+	what = what.orAll()
+
+	if what&COUNT_LINES == 0 {
+		c.lines = 0
+	}
+	if what&COUNT_WORDS == 0 {
+		c.words = 0
+	}
+	if what&COUNT_CHARS == 0 {
+		c.chars = 0
+	}
+	if what&COUNT_BYTES == 0 {
+		c.bytes = 0
+	}
+	if what&COUNT_MAX_LINE_LENGTH == 0 {
+		c.maxLineLength = 0
+	}
+	if what&COUNT_STATS == 0 {
+		c.stats = nil
+	}
+
+	return c
+}
+
+// Add sums up the counts of both values. The max line length is not a sum, the
+// longest of the two is kept instead
 func (c Counts) Add(other Counts) Counts {
 	c.lines += other.lines
 	c.words += other.words
+	c.chars += other.chars
 	c.bytes += other.bytes
+	c.maxLineLength = max(c.maxLineLength, other.maxLineLength)
+	c.compressedBytes += other.compressedBytes
+	c.stats = c.stats.merge(other.stats)
 	return c
 }
 
+// Sub returns the difference of every count, such as what a file grew by
+// since it was last counted. Counts that would go below zero are left at zero.
+// The max line length and the stats can't be subtracted, the ones of c are
+// kept
+func (c Counts) Sub(other Counts) Counts {
+	c.lines -= min(c.lines, other.lines)
+	c.words -= min(c.words, other.words)
+	c.chars -= min(c.chars, other.chars)
+	c.bytes -= min(c.bytes, other.bytes)
+	c.compressedBytes -= min(c.compressedBytes, other.compressedBytes)
+	return c
+}
+
+// Equal reports whether both hold the same counts and stats
+func (c Counts) Equal(other Counts) bool {
+	return c.lines == other.lines &&
+		c.words == other.words &&
+		c.chars == other.chars &&
+		c.bytes == other.bytes &&
+		c.maxLineLength == other.maxLineLength &&
+		c.compressedBytes == other.compressedBytes &&
+		c.stats.equal(other.stats)
+}
+
+// MarshalText writes the counts as space separated key=value pairs, such as
+// "lines=1 words=5 chars=24 bytes=24 max_line_length=23". The compressed bytes
+// are only written when set and the stats never are
+func (c Counts) MarshalText() ([]byte, error) {
+	text := fmt.Appendf(nil, "lines=%d words=%d chars=%d bytes=%d max_line_length=%d",
+		c.lines, c.words, c.chars, c.bytes, c.maxLineLength)
+
+	if c.compressedBytes > 0 {
+		text = fmt.Appendf(text, " compressed_bytes=%d", c.compressedBytes)
+	}
+
+	return text, nil
+}
+
+// MarshalJSON writes the counts with the same keys as the records of the JSON
+// output
+func (c Counts) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Values())
+}
+
+// Values returns the counts in a form the display package can serialize
+func (c Counts) Values() display.Values {
+	return display.Values{
+		Lines:         c.lines,
+		Words:         c.words,
+		Chars:         c.chars,
+		Bytes:         c.bytes,
+		MaxLineLength: c.maxLineLength,
+
+		CompressedBytes: c.compressedBytes,
+		Stats:           c.stats.Values(),
+	}
+}
+
+// Stats returns the stats of the lines, nil unless they were collected with
+// COUNT_STATS
+func (c Counts) Stats() *Stats {
+	return c.stats
+}
+
 func CountFile(filename string) (Counts, error) {
+	return CountFileContext(context.Background(), filename)
+}
+
+// CountFileContext counts the file until it's done or the context is
+// cancelled, in which case the counts up to that point are returned along
+// with the context's error. Failing to read the file mid way returns the
+// partial counts and the read error as well
+func CountFileContext(ctx context.Context, filename string) (Counts, error) {
+	return CountFileWith(ctx, filename, FileOptions{})
+}
+
+// CountFileWith works like CountFileContext. Regular files of at least
+// opts.ParallelThreshold bytes are split in chunks that are counted
+// concurrently. With opts.Mmap regular files are mapped in memory and counted
+// in place, pipes, special files and files that can't be mapped are read as
+// usual. With opts.Decompress compressed files are decompressed as they are
+// read and counted sequentially
+func CountFileWith(ctx context.Context, filename string, opts FileOptions) (Counts, error) {
 	file, err := os.Open(filename)
+	if err != nil {
+		return Counts{}, err
+	}
 	defer file.Close()
 
+	counts, err := CountOpenFile(ctx, file, opts)
+
+	// errors from the file already hold its name
+	var pathErr *fs.PathError
+	if err != nil && !errors.As(err, &pathErr) {
+		return counts, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return counts, err
+}
+
+// CountOpenFile counts an already open file from its current position, such
+// as stdin, the same way CountFileWith does. Memory mapping and parallel
+// counting are only used when the position is at the start of the file
+func CountOpenFile(ctx context.Context, file *os.File, opts FileOptions) (Counts, error) {
+	r := opts.withProgress(file)
+
+	if opts.Decompress {
+		return countDecompressed(ctx, r, opts.What, opts.Words)
+	}
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return getCountsWith(ctx, r, opts.What, opts.Words)
+	}
+
+	offset, err := file.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return Counts{}, err
+		return getCountsWith(ctx, r, opts.What, opts.Words)
 	}
 
-	return GetCounts(file), nil
+	size := info.Size()
+
+	switch {
+	case opts.What == COUNT_BYTES && size > 0:
+		// files in /proc and the like report a size of zero and have to be read
+		n := max(size-offset, 0)
+		if opts.Progress != nil {
+			opts.Progress(int(n))
+		}
+		return Counts{bytes: uint(n)}, nil
+	case offset != 0:
+		return getCountsWith(ctx, r, opts.What, opts.Words)
+	case opts.Mmap:
+		counts, mapped, err := countMapped(ctx, file, size, opts)
+		if mapped {
+			return counts, err
+		}
+		return getCountsWith(ctx, r, opts.What, opts.Words)
+	case opts.splits(size):
+		return getCountsChunked(ctx, file, size, opts.chunks(), MIN_CHUNK_SIZE, opts)
+	}
+
+	return getCountsWith(ctx, r, opts.What, opts.Words)
 }
 
 // By making our argument accept any value that conforms to the io.Reader interface
 // we are able to accept various data types such as files or a slice of bytes
 func CountWords(data io.Reader) uint {
-	wordCount := uint(0)
-
-	// Create scanner
-	scanner := bufio.NewScanner(data)
-	// Defines the function that Split will use
-	scanner.Split(bufio.ScanWords)
+	wordCount, _ := CountWordsErr(data)
+	return wordCount
+}
 
-	for scanner.Scan() {
-		wordCount++
-	}
+// CountWordsErr counts the words up to EOF. When reading fails the words
+// counted so far are returned along with the error
+func CountWordsErr(data io.Reader) (uint, error) {
+	return CountWordsWith(data, WhitespaceWords)
+}
 
-	return wordCount
+// CountWordsWith counts the words up to EOF as defined by the rule. When
+// reading fails the words counted so far are returned along with the error
+func CountWordsWith(data io.Reader, rule WordRule) (uint, error) {
+	counts, err := getCountsBlocks(data, COUNT_WORDS, rule)
+	return counts.words, err
 }
 
 func CountLines(r io.Reader) uint {
+	linesCount, _ := CountLinesErr(r)
+	return linesCount
+}
+
+// CountLinesErr counts the lines up to EOF. When reading fails the lines
+// counted so far are returned along with the error
+func CountLinesErr(r io.Reader) (uint, error) {
 	linesCount := uint(0)
 
 	reader := bufio.NewReader(r)
@@ -61,31 +340,104 @@ func CountLines(r io.Reader) uint {
 	for {
 		r, _, err := reader.ReadRune()
 		if err != nil {
-			break
+			return linesCount, readErr(err)
 		}
 		if r == '\n' {
 			linesCount++
 		}
 	}
+}
+
+// CountChars counts the number of UTF-8 encoded runes in the reader. Invalid
+// bytes are counted as one character each, same as ReadRune reports them
+func CountChars(r io.Reader) uint {
+	charsCount, _ := CountCharsErr(r)
+	return charsCount
+}
+
+// CountCharsErr counts the characters up to EOF. When reading fails the
+// characters counted so far are returned along with the error
+func CountCharsErr(r io.Reader) (uint, error) {
+	charsCount := uint(0)
+
+	reader := bufio.NewReader(r)
+
+	for {
+		_, _, err := reader.ReadRune()
+		if err != nil {
+			return charsCount, readErr(err)
+		}
+		charsCount++
+	}
+}
 
-	return linesCount
+// CountMaxLineLength returns the display width of the longest line. Tabs are
+// expanded to the next multiple of 8 columns and wide runes take two columns
+func CountMaxLineLength(r io.Reader) uint {
+	maxLength, _ := CountMaxLineLengthErr(r)
+	return maxLength
+}
+
+// CountMaxLineLengthErr measures the longest line up to EOF. When reading
+// fails the longest line seen so far is returned along with the error
+func CountMaxLineLengthErr(r io.Reader) (uint, error) {
+	maxLength := uint(0)
+	col := uint(0)
+
+	reader := bufio.NewReader(r)
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return max(maxLength, col), readErr(err)
+		}
+
+		switch r {
+		case '\n', '\r', '\f':
+			maxLength = max(maxLength, col)
+			col = 0
+		default:
+			col = advanceColumn(col, r)
+		}
+	}
 }
 
 func CountBytes(r io.Reader) uint {
-	byteCount, _ := io.Copy(io.Discard, r)
-	return uint(byteCount)
+	byteCount, _ := CountBytesErr(r)
+	return byteCount
+}
+
+// CountBytesErr counts the bytes up to EOF. When reading fails the bytes read
+// so far are returned along with the error
+func CountBytesErr(r io.Reader) (uint, error) {
+	byteCount, err := io.Copy(io.Discard, r)
+	return uint(byteCount), err
+}
+
+// readErr turns the error that ended a read loop into the error to return,
+// reaching EOF is not a failure
+func readErr(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
 }
 
 func getCountsConcurrent(r io.Reader) Counts {
 	linesReader, linesWriter := io.Pipe()
 	wordsReader, wordsWriter := io.Pipe()
+	charsReader, charsWriter := io.Pipe()
 	bytesReader, bytesWriter := io.Pipe()
+	maxLineReader, maxLineWriter := io.Pipe()
 
-	w := io.MultiWriter(linesWriter, wordsWriter, bytesWriter)
+	w := io.MultiWriter(linesWriter, wordsWriter, charsWriter, bytesWriter, maxLineWriter)
 
 	linesChan := make(chan uint)
 	wordsChan := make(chan uint)
+	charsChan := make(chan uint)
 	bytesChan := make(chan uint)
+	maxLineChan := make(chan uint)
 
 	go func() {
 		defer close(linesChan)
@@ -97,41 +449,65 @@ func getCountsConcurrent(r io.Reader) Counts {
 		wordsChan <- CountWords(wordsReader)
 	}()
 
+	go func() {
+		defer close(charsChan)
+		charsChan <- CountChars(charsReader)
+	}()
+
 	go func() {
 		defer close(bytesChan)
 		bytesChan <- CountBytes(bytesReader)
 	}()
 
+	go func() {
+		defer close(maxLineChan)
+		maxLineChan <- CountMaxLineLength(maxLineReader)
+	}()
+
 	io.Copy(w, r)
 
 	linesWriter.Close()
 	wordsWriter.Close()
+	charsWriter.Close()
 	bytesWriter.Close()
+	maxLineWriter.Close()
 
 	return Counts{
-		<-linesChan,
-		<-wordsChan,
-		<-bytesChan,
+		lines:         <-linesChan,
+		words:         <-wordsChan,
+		chars:         <-charsChan,
+		bytes:         <-bytesChan,
+		maxLineLength: <-maxLineChan,
 	}
 }
 
-func getCountsSinglePass(r io.Reader) Counts {
+func getCountsSinglePass(r io.Reader) (Counts, error) {
 	res := Counts{}
 
 	isInsideWord := false
+	col := uint(0)
 	reader := bufio.NewReader(r)
 
 	for {
 		r, size, err := reader.ReadRune()
 
 		if err != nil {
-			break
+			res.maxLineLength = max(res.maxLineLength, col)
+			return res, readErr(err)
 		}
 
+		res.chars++
 		res.bytes += uint(size)
 
-		if r == '\n' {
+		switch r {
+		case '\n':
 			res.lines++
+			fallthrough
+		case '\r', '\f':
+			res.maxLineLength = max(res.maxLineLength, col)
+			col = 0
+		default:
+			col = advanceColumn(col, r)
 		}
 
 		isSpace := unicode.IsSpace(r)
@@ -142,35 +518,63 @@ func getCountsSinglePass(r io.Reader) Counts {
 
 		isInsideWord = !isSpace
 	}
-
-	return res
 }
 
+// GetCounts counts the reader up to EOF. Read errors are ignored, use
+// GetCountsErr to find out whether the counts are complete
 func GetCounts(r io.Reader) Counts {
-	return getCountsSinglePass(r)
+	counts, _ := GetCountsErr(r)
+	return counts
 }
 
-func (c Counts) Print(w io.Writer, opts display.Options, suffixes ...string) {
-	stats := []string{}
+// GetCountsErr counts the reader up to EOF. When reading fails the counts up
+// to that point are returned along with the error
+func GetCountsErr(r io.Reader) (Counts, error) {
+	return getCountsBlocks(r, COUNT_ALL, WhitespaceWords)
+}
 
-	if opts.ShouldShowLines() {
-		stats = append(stats, strconv.Itoa(int(c.lines)))
-	}
-	if opts.ShouldShowWords() {
-		stats = append(stats, strconv.Itoa(int(c.words)))
-	}
-	if opts.ShouldShowBytes() {
-		stats = append(stats, strconv.Itoa(int(c.bytes)))
+// contextReader stops reading once its context is cancelled. The context is
+// only checked between reads, a read that is already blocked is not
+// interrupted
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
 	}
 
-	line := strings.Join(stats, "\t") + "\t"
-	suffixStr := strings.Join(suffixes, " ")
+	return cr.r.Read(p)
+}
 
-	fmt.Fprintf(w, "%s", line)
+// GetCountsContext counts the reader until EOF or until the context is
+// cancelled, in which case the counts up to that point are returned along
+// with the context's error. Read errors are returned the same way
+func GetCountsContext(ctx context.Context, r io.Reader) (Counts, error) {
+	return GetCountsErr(contextReader{ctx: ctx, r: r})
+}
 
-	if suffixStr != "" {
-		fmt.Fprintf(w, " %s", suffixStr)
-	}
+// GetCountsWith counts the reader up to EOF computing only the selected
+// counts, the rest are left at zero. Skipping words and the max line length
+// avoids classifying every rune, and counting only lines and bytes avoids
+// decoding UTF-8 altogether. Read errors are returned along with the counts
+// up to that point
+func GetCountsWith(r io.Reader, what CountOptions) (Counts, error) {
+	return GetCountsWithRule(r, what, WhitespaceWords)
+}
+
+// GetCountsWithRule works like GetCountsWith finding words with the rule
+func GetCountsWithRule(r io.Reader, what CountOptions, rule WordRule) (Counts, error) {
+	return getCountsBlocks(r, what, rule)
+}
+
+// getCountsWith works like GetCountsWithRule until the context is cancelled
+func getCountsWith(ctx context.Context, r io.Reader, what CountOptions, rule WordRule) (Counts, error) {
+	return GetCountsWithRule(contextReader{ctx: ctx, r: r}, what, rule)
+}
 
-	fmt.Fprintf(w, "\n")
+func (c Counts) Print(w io.Writer, opts display.Options, suffixes ...string) {
+	opts.PrintRow(w, c.Values(), suffixes...)
 }
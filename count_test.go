@@ -2,8 +2,16 @@ package counter
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"bloom.io/github.com/FerDev12/wc-go/display"
 	"bloom.io/github.com/FerDev12/wc-go/test/assert"
@@ -60,6 +68,66 @@ func TestCountLines(t *testing.T) {
 	}
 }
 
+func TestCountChars(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		wants uint
+	}{
+		{name: "empty string", input: "", wants: 0},
+		{name: "ascii word", input: "hello", wants: 5},
+		{name: "newlines and words", input: "one\ntwo\n", wants: 8},
+		{name: "two byte runes", input: "thrРee", wants: 6},
+		{name: "three byte runes", input: "one\u2007two", wants: 7},
+		{name: "four byte runes", input: "😀 😀", wants: 3},
+		{name: "invalid utf8", input: "a\xffb", wants: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := strings.NewReader(tc.input)
+			assert.Equal(t, tc.wants, GetCounts(r).chars, "GetCounts")
+
+			r = strings.NewReader(tc.input)
+			assert.Equal(t, tc.wants, CountChars(r), "CountChars")
+		})
+	}
+}
+
+func TestCountMaxLineLength(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		wants uint
+	}{
+		{name: "empty string", input: "", wants: 0},
+		{name: "single line", input: "hello", wants: 5},
+		{name: "longest line first", input: "one two\nthree\n", wants: 7},
+		{name: "longest line last", input: "one\nthree four", wants: 10},
+		{name: "newline is not counted", input: "abc\n", wants: 3},
+		{name: "tab expands to next stop", input: "a\tb", wants: 9},
+		{name: "tab on a stop", input: "12345678\tb", wants: 17},
+		{name: "consecutive tabs", input: "\t\t", wants: 16},
+		{name: "carriage return resets", input: "abcdef\rab", wants: 6},
+		{name: "form feed resets", input: "ab\fabcd", wants: 4},
+		{name: "wide runes", input: "日本語", wants: 6},
+		{name: "emoji", input: "hi 😀", wants: 5},
+		{name: "combining marks", input: "e\u0301e\u0301", wants: 2},
+		{name: "multi byte narrow runes", input: "thrРee", wants: 6},
+		{name: "control characters", input: "a\x00b", wants: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := strings.NewReader(tc.input)
+			assert.Equal(t, tc.wants, GetCounts(r).maxLineLength, "GetCounts")
+
+			r = strings.NewReader(tc.input)
+			assert.Equal(t, tc.wants, CountMaxLineLength(r), "CountMaxLineLength")
+		})
+	}
+}
+
 func TestCountBytes(t *testing.T) {
 	testCases := []struct {
 		name  string
@@ -94,69 +162,95 @@ func TestGetCounts(t *testing.T) {
 			name:  "five words",
 			input: "one two three four five\n",
 			wants: Counts{
-				lines: 1,
-				words: 5,
-				bytes: 24,
+				lines:         1,
+				words:         5,
+				chars:         24,
+				bytes:         24,
+				maxLineLength: 23,
 			}},
 		{name: "empty string", input: "", wants: Counts{
-			lines: 0,
-			words: 0,
-			bytes: 0,
+			lines:         0,
+			words:         0,
+			chars:         0,
+			bytes:         0,
+			maxLineLength: 0,
 		}},
 		{name: "single space", input: " ", wants: Counts{
-			lines: 0,
-			words: 0,
-			bytes: 1,
+			lines:         0,
+			words:         0,
+			chars:         1,
+			bytes:         1,
+			maxLineLength: 1,
 		}},
 		{name: "new line", input: "one\ntwo", wants: Counts{
-			lines: 1,
-			words: 2,
-			bytes: 7,
+			lines:         1,
+			words:         2,
+			chars:         7,
+			bytes:         7,
+			maxLineLength: 3,
 		}},
 		{name: "multiple spaces", input: "one   two", wants: Counts{
-			lines: 0,
-			words: 2,
-			bytes: 9,
+			lines:         0,
+			words:         2,
+			chars:         9,
+			bytes:         9,
+			maxLineLength: 9,
 		}},
 		{name: "prefixed multiple spaces", input: "   one two\n", wants: Counts{
-			lines: 1,
-			words: 2,
-			bytes: 11,
+			lines:         1,
+			words:         2,
+			chars:         11,
+			bytes:         11,
+			maxLineLength: 10,
 		}},
 		{name: "suffixed multiple spaces", input: "one two   \n", wants: Counts{
-			lines: 1,
-			words: 2,
-			bytes: 11,
+			lines:         1,
+			words:         2,
+			chars:         11,
+			bytes:         11,
+			maxLineLength: 10,
 		}},
 		{name: "tab characters", input: "	one two		three\n", wants: Counts{
-			lines: 1,
-			words: 3,
-			bytes: 16,
+			lines:         1,
+			words:         3,
+			chars:         16,
+			bytes:         16,
+			maxLineLength: 29,
 		}},
 		{name: "utf8 characters", input: "one two three four five six", wants: Counts{
-			lines: 0,
-			words: 6,
-			bytes: 37,
+			lines:         0,
+			words:         6,
+			chars:         27,
+			bytes:         37,
+			maxLineLength: 27,
 		}},
 		{name: "unicode characters", input: "one two thrРee four five", wants: Counts{
-			lines: 0,
-			words: 5,
-			bytes: 25,
+			lines:         0,
+			words:         5,
+			chars:         24,
+			bytes:         25,
+			maxLineLength: 24,
 		}},
 		{name: "no new line at end", input: "one two three four five\n six", wants: Counts{
-			lines: 1,
-			words: 6,
-			bytes: 28,
+			lines:         1,
+			words:         6,
+			chars:         28,
+			bytes:         28,
+			maxLineLength: 23,
 		}},
 		{name: "multi newline string", input: "\n\n\n\n", wants: Counts{
-			lines: 4,
-			words: 0,
-			bytes: 4,
+			lines:         4,
+			words:         0,
+			chars:         4,
+			bytes:         4,
+			maxLineLength: 0,
 		}},
 		{name: "multi word and newline string", input: "one\ntwo\nthree\nfour\nfive\n", wants: Counts{
-			lines: 5,
-			words: 5,
-			bytes: 24,
+			lines:         5,
+			words:         5,
+			chars:         24,
+			bytes:         24,
+			maxLineLength: 5,
 		}},
 	}
 
@@ -169,6 +263,175 @@ func TestGetCounts(t *testing.T) {
 	}
 }
 
+var errRead = errors.New("input/output error")
+
+func TestCountsErr(t *testing.T) {
+	input := "one two\nthree\n"
+
+	type counter func(r io.Reader) (uint, error)
+
+	testCases := []struct {
+		name    string
+		counter counter
+		wants   uint
+		partial uint
+	}{
+		{name: "words", counter: CountWordsErr, wants: 3, partial: 3},
+		{name: "lines", counter: CountLinesErr, wants: 2, partial: 2},
+		{name: "chars", counter: CountCharsErr, wants: 14, partial: 14},
+		{name: "bytes", counter: CountBytesErr, wants: 14, partial: 14},
+		{name: "max line length", counter: CountMaxLineLengthErr, wants: 7, partial: 7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.counter(strings.NewReader(input))
+			assert.Equal(t, nil, err, "EOF is not an error")
+			assert.Equal(t, tc.wants, got)
+
+			r := io.MultiReader(strings.NewReader(input), iotest.ErrReader(errRead))
+			got, err = tc.counter(r)
+			assert.Equal(t, errRead, err, "read error")
+			assert.Equal(t, tc.partial, got, "partial count")
+		})
+	}
+}
+
+func TestGetCountsErr(t *testing.T) {
+	input := "one two\nthree\n"
+
+	got, err := GetCountsErr(strings.NewReader(input))
+	assert.Equal(t, nil, err, "EOF is not an error")
+	assert.Equal(t, GetCounts(strings.NewReader(input)), got)
+
+	r := io.MultiReader(strings.NewReader(input), iotest.ErrReader(errRead))
+	got, err = GetCountsErr(r)
+	assert.Equal(t, errRead, err, "read error")
+	assert.Equal(t, Counts{lines: 2, words: 3, chars: 14, bytes: 14, maxLineLength: 7}, got)
+
+	r = io.MultiReader(strings.NewReader(input), iotest.ErrReader(errRead))
+	assert.Equal(t, uint(14), GetCounts(r).bytes, "GetCounts ignores the error")
+}
+
+func TestCountOpenFileOnlyBytes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte("one two three\nfour five\n"), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal("failed to open file:", err)
+	}
+	defer file.Close()
+
+	got, err := CountOpenFile(context.Background(), file, FileOptions{What: COUNT_BYTES})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{bytes: 24}, got, "from the start")
+
+	// only the bytes after the current position are counted
+	if _, err := file.Seek(4, io.SeekStart); err != nil {
+		t.Fatal("failed to seek:", err)
+	}
+
+	got, err = CountOpenFile(context.Background(), file, FileOptions{What: COUNT_BYTES})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{bytes: 20}, got, "from an offset")
+
+	got, err = CountOpenFile(context.Background(), file, FileOptions{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{lines: 2, words: 4, chars: 20, bytes: 20, maxLineLength: 9}, got, "reading from an offset")
+}
+
+func TestCountFileOnlyBytesZeroSize(t *testing.T) {
+	// files in /proc report a size of zero, they can only be read
+	filename := "/proc/self/status"
+	if _, err := os.Stat(filename); err != nil {
+		t.Skip("no /proc file system")
+	}
+
+	got, err := CountFileWith(context.Background(), filename, FileOptions{What: COUNT_BYTES})
+	assert.Equal(t, nil, err)
+
+	if got.bytes == 0 {
+		t.Error("expected the file to be read")
+	}
+}
+
+func TestCountFileDirectory(t *testing.T) {
+	dname := t.TempDir()
+
+	_, err := CountFile(dname)
+	if err == nil {
+		t.Fatal("counting a directory should fail")
+	}
+
+	assert.Equal(t, "read "+dname+": is a directory", err.Error())
+}
+
+// cancellingReader cancels the context after the first read
+type cancellingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (cr cancellingReader) Read(p []byte) (int, error) {
+	defer cr.cancel()
+	return cr.r.Read(p)
+}
+
+func TestGetCountsContext(t *testing.T) {
+	input := strings.Repeat("one two\n", BLOCK_SIZE/4)
+
+	t.Run("not cancelled", func(t *testing.T) {
+		got, err := GetCountsContext(context.Background(), strings.NewReader(input))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, GetCounts(strings.NewReader(input)), got)
+	})
+
+	t.Run("cancelled before reading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, err := GetCountsContext(ctx, strings.NewReader(input))
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, Counts{}, got)
+	})
+
+	t.Run("cancelled while reading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := cancellingReader{r: strings.NewReader(input), cancel: cancel}
+
+		got, err := GetCountsContext(ctx, r)
+		assert.Equal(t, context.Canceled, err)
+
+		if got.bytes == 0 || got.bytes >= uint(len(input)) {
+			t.Errorf("expected partial counts, got %d of %d bytes", got.bytes, len(input))
+		}
+		assert.Equal(t, got.bytes/8, got.lines, "lines of partial counts")
+	})
+}
+
+func TestCountFileContext(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte("one two three\n"), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	got, err := CountFileContext(context.Background(), filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{lines: 1, words: 3, chars: 14, bytes: 14, maxLineLength: 13}, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = CountFileContext(ctx, filename)
+	assert.Equal(t, true, errors.Is(err, context.Canceled), "error should wrap context.Canceled")
+	assert.Equal(t, filename+": context canceled", err.Error())
+}
+
 func TestAddCounts(t *testing.T) {
 	testCases := []struct {
 		name  string
@@ -230,6 +493,27 @@ func TestAddCounts(t *testing.T) {
 				bytes: 9,
 			},
 		},
+		{
+			name: "max line length keeps the longest",
+			input: []Counts{
+				{
+					lines:         1,
+					maxLineLength: 12,
+				},
+				{
+					lines:         2,
+					maxLineLength: 40,
+				},
+				{
+					lines:         3,
+					maxLineLength: 7,
+				},
+			},
+			wants: Counts{
+				lines:         6,
+				maxLineLength: 40,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -243,6 +527,127 @@ func TestAddCounts(t *testing.T) {
 	}
 }
 
+func TestNewCounts(t *testing.T) {
+	counts := NewCounts(NewCountsArgs{Lines: 1, Words: 5, Chars: 23, Bytes: 24, MaxLineLength: 22, CompressedBytes: 40})
+
+	assert.Equal(t, uint(1), counts.Lines())
+	assert.Equal(t, uint(5), counts.Words())
+	assert.Equal(t, uint(23), counts.Chars())
+	assert.Equal(t, uint(24), counts.Bytes())
+	assert.Equal(t, uint(22), counts.MaxLineLength())
+	assert.Equal(t, uint(40), counts.CompressedBytes())
+	assert.Equal(t, (*Stats)(nil), counts.Stats())
+
+	assert.Equal(t, Counts{}, NewCounts(NewCountsArgs{}))
+}
+
+func TestSubCounts(t *testing.T) {
+	testCases := []struct {
+		name  string
+		c     Counts
+		other Counts
+		wants Counts
+	}{
+		{
+			name:  "grown file",
+			c:     Counts{lines: 10, words: 20, chars: 90, bytes: 100, maxLineLength: 30},
+			other: Counts{lines: 4, words: 8, chars: 40, bytes: 45, maxLineLength: 12},
+			wants: Counts{lines: 6, words: 12, chars: 50, bytes: 55, maxLineLength: 30},
+		},
+		{
+			name:  "below zero",
+			c:     Counts{lines: 1, bytes: 10, compressedBytes: 5},
+			other: Counts{lines: 2, bytes: 4, compressedBytes: 8},
+			wants: Counts{lines: 0, bytes: 6},
+		},
+		{
+			name:  "zero",
+			c:     Counts{lines: 3, words: 4},
+			other: Counts{},
+			wants: Counts{lines: 3, words: 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wants, tc.c.Sub(tc.other))
+		})
+	}
+
+	t.Run("undoes add", func(t *testing.T) {
+		a := Counts{lines: 1, words: 2, chars: 3, bytes: 4}
+		b := Counts{lines: 5, words: 6, chars: 7, bytes: 8}
+		assert.Equal(t, a, a.Add(b).Sub(b))
+	})
+}
+
+func TestEqualCounts(t *testing.T) {
+	stats := func(input string) *Stats {
+		counts, _ := GetCountsWith(strings.NewReader(input), COUNT_STATS)
+		return counts.stats
+	}
+
+	testCases := []struct {
+		name  string
+		a     Counts
+		b     Counts
+		wants bool
+	}{
+		{name: "zero", a: Counts{}, b: Counts{}, wants: true},
+		{name: "same", a: Counts{lines: 1, words: 2, bytes: 3}, b: NewCounts(NewCountsArgs{Lines: 1, Words: 2, Bytes: 3}), wants: true},
+		{name: "different lines", a: Counts{lines: 1}, b: Counts{lines: 2}, wants: false},
+		{name: "different max line length", a: Counts{maxLineLength: 1}, b: Counts{maxLineLength: 2}, wants: false},
+		{name: "different compressed bytes", a: Counts{compressedBytes: 1}, b: Counts{}, wants: false},
+		{name: "same stats", a: Counts{stats: stats("a\nbb\n")}, b: Counts{stats: stats("bb\na\n")}, wants: true},
+		{name: "different stats", a: Counts{stats: stats("a\nbb\n")}, b: Counts{stats: stats("a\nb\n")}, wants: false},
+		{name: "missing stats", a: Counts{stats: stats("a\n")}, b: Counts{}, wants: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wants, tc.a.Equal(tc.b))
+			assert.Equal(t, tc.wants, tc.b.Equal(tc.a))
+		})
+	}
+}
+
+func TestMarshalCounts(t *testing.T) {
+	counts := Counts{lines: 1, words: 5, chars: 23, bytes: 24, maxLineLength: 22}
+
+	text, err := counts.MarshalText()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "lines=1 words=5 chars=23 bytes=24 max_line_length=22", string(text))
+
+	counts.compressedBytes = 12
+
+	text, err = counts.MarshalText()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "lines=1 words=5 chars=23 bytes=24 max_line_length=22 compressed_bytes=12", string(text))
+
+	data, err := json.Marshal(map[string]Counts{"words.txt": counts})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"words.txt":{"lines":1,"words":5,"chars":23,"bytes":24,"max_line_length":22,"compressed_bytes":12}}`, string(data))
+
+	counts, _ = GetCountsWith(strings.NewReader("one\n"), COUNT_LINES|COUNT_STATS)
+
+	data, err = json.Marshal(counts)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(data), `"stats":{"line_bytes":{"min":3,"max":3,"mean":3,`), string(data))
+}
+
+func TestCountsValues(t *testing.T) {
+	counts := Counts{
+		lines:         1,
+		words:         5,
+		chars:         23,
+		bytes:         24,
+		maxLineLength: 22,
+	}
+
+	wants := display.Values{Lines: 1, Words: 5, Chars: 23, Bytes: 24, MaxLineLength: 22}
+	assert.Equal(t, wants, counts.Values())
+}
+
 func TestPrintCounts(t *testing.T) {
 	type inputs struct {
 		counts   Counts
@@ -390,6 +795,58 @@ func TestPrintCounts(t *testing.T) {
 			},
 			wants: "1\t5\t words.txt\n",
 		},
+		{
+			name: "unicode show chars",
+			input: inputs{
+				counts: Counts{
+					lines: 0,
+					words: 5,
+					chars: 24,
+					bytes: 25,
+				},
+				filename: []string{"words.txt"},
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowChars: true,
+				}),
+			},
+			wants: "24\t words.txt\n",
+		},
+		{
+			name: "unicode show chars and bytes",
+			input: inputs{
+				counts: Counts{
+					lines: 0,
+					words: 5,
+					chars: 24,
+					bytes: 25,
+				},
+				filename: []string{"words.txt"},
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowLines: true,
+					ShowWords: true,
+					ShowChars: true,
+					ShowBytes: true,
+				}),
+			},
+			wants: "0\t5\t24\t25\t words.txt\n",
+		},
+		{
+			name: "show max line length",
+			input: inputs{
+				counts: Counts{
+					lines:         2,
+					words:         5,
+					bytes:         24,
+					maxLineLength: 13,
+				},
+				filename: []string{"words.txt"},
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowLines:         true,
+					ShowMaxLineLength: true,
+				}),
+			},
+			wants: "2\t13\t words.txt\n",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -408,22 +865,99 @@ var benchData = []string{
 	"this is a weird string.",
 }
 
+// BenchmarkGetCounts counts the short strings of benchData and compares the
+// engines on a large input, 1 GiB by default. Run with -bench-size to change
+// it
 func BenchmarkGetCounts(b *testing.B) {
+	b.Run("short", func(b *testing.B) {
+		i := 0
+		for b.Loop() {
+			data := benchData[i%len(benchData)]
+			r := strings.NewReader(data)
+			getCountsConcurrent(r)
+			i++
+		}
+	})
+
+	b.Run("large single pass", func(b *testing.B) {
+		benchmarkLarge(b, getCountsSinglePass)
+	})
+
+	b.Run("large blocks", func(b *testing.B) {
+		benchmarkLarge(b, GetCountsErr)
+	})
+
+	selections := []struct {
+		name string
+		what CountOptions
+	}{
+		{name: "lines", what: COUNT_LINES},
+		{name: "chars", what: COUNT_CHARS},
+		{name: "words", what: COUNT_WORDS},
+	}
+
+	for _, sel := range selections {
+		b.Run("large blocks "+sel.name, func(b *testing.B) {
+			benchmarkLarge(b, func(r io.Reader) (Counts, error) {
+				return GetCountsWith(r, sel.what)
+			})
+		})
+	}
+}
+
+func BenchmarkGetCountsSinglePass(b *testing.B) {
 	i := 0
 	for b.Loop() {
 		data := benchData[i%len(benchData)]
 		r := strings.NewReader(data)
-		getCountsConcurrent(r)
+		getCountsSinglePass(r)
 		i++
 	}
 }
 
-func BenchmarkGetCountsSinglePass(b *testing.B) {
+func BenchmarkGetCountsBlocks(b *testing.B) {
 	i := 0
 	for b.Loop() {
 		data := benchData[i%len(benchData)]
 		r := strings.NewReader(data)
-		getCountsSinglePass(r)
+		getCountsBlocks(r, COUNT_ALL, nil)
 		i++
 	}
 }
+
+var benchSize = flag.Int64("bench-size", 1<<30, "size in bytes of the input of the large benchmarks")
+
+// benchLine mixes plain ASCII, tabs and a few multi byte runes, like a log
+var benchLine = []byte("2025-01-01T00:00:00Z\tINFO\trequest served path=/api/v1/users status=200 user=José latency=12ms\n")
+
+// repeatReader produces size bytes by repeating benchLine without holding the
+// whole input in memory
+type repeatReader struct {
+	size int64
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.size <= 0 {
+		return 0, io.EOF
+	}
+
+	p = p[:min(int64(len(p)), r.size)]
+	n := 0
+	for n < len(p) {
+		copied := copy(p[n:], benchLine[r.off:])
+		r.off = (r.off + copied) % len(benchLine)
+		n += copied
+	}
+
+	r.size -= int64(n)
+	return n, nil
+}
+
+func benchmarkLarge(b *testing.B, count func(io.Reader) (Counts, error)) {
+	b.SetBytes(*benchSize)
+
+	for b.Loop() {
+		count(&repeatReader{size: *benchSize})
+	}
+}
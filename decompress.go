@@ -0,0 +1,89 @@
+package counter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+)
+
+// countingReader keeps track of the number of bytes read through it
+type countingReader struct {
+	r io.Reader
+	n uint
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += uint(n)
+	return n, err
+}
+
+// isZlib reports whether the header is a zlib header with the 32 KiB window
+// every zlib writer uses. Other window sizes are ignored, as plain text starts
+// with a valid header too often
+func isZlib(header []byte) bool {
+	if len(header) < 2 || header[0] != 0x78 {
+		return false
+	}
+
+	switch header[1] {
+	case 0x01, 0x5e, 0x9c, 0xda:
+		return true
+	}
+
+	return false
+}
+
+// isBzip2 reports whether the header is a bzip2 header, "BZh" followed by the
+// block size
+func isBzip2(header []byte) bool {
+	return len(header) >= 4 && bytes.HasPrefix(header, []byte("BZh")) && header[3] >= '1' && header[3] <= '9'
+}
+
+// decompressReader sniffs the magic bytes at the start of the reader and
+// returns a reader decompressing it. Data that is not compressed in a known
+// format is returned as it is
+func decompressReader(r *bufio.Reader) (io.Reader, error) {
+	// a short input can't be compressed and Peek returns whatever there is
+	header, _ := r.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(header, []byte{0x1f, 0x9d}):
+		return newUnixCompressReader(r)
+	case isBzip2(header):
+		return bzip2.NewReader(r), nil
+	case isZlib(header):
+		return zlib.NewReader(r)
+	}
+
+	return r, nil
+}
+
+// countDecompressed counts the decompressed contents of the reader. The
+// number of bytes read from it is set as the compressed byte count, unless it
+// was not compressed
+func countDecompressed(ctx context.Context, r io.Reader, what CountOptions, rule WordRule) (Counts, error) {
+	raw := &countingReader{r: contextReader{ctx: ctx, r: r}}
+
+	buffered := bufio.NewReader(raw)
+
+	decompressed, err := decompressReader(buffered)
+	if err != nil {
+		return Counts{compressedBytes: raw.n}, err
+	}
+
+	counts, err := getCountsBlocks(decompressed, what, rule)
+
+	// data that is not compressed is returned as it is
+	if decompressed != io.Reader(buffered) {
+		counts.compressedBytes = raw.n
+	}
+
+	return counts, err
+}
@@ -0,0 +1,184 @@
+package counter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+// writeCompressed writes the content compressed with the writer created by
+// newWriter to a file in dir
+func writeCompressed(t *testing.T, dir, name string, content []byte, newWriter func(io.Writer) io.WriteCloser) string {
+	t.Helper()
+
+	buffer := bytes.Buffer{}
+	w := newWriter(&buffer)
+	if _, err := w.Write(content); err != nil {
+		t.Fatal("failed to compress:", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("failed to compress:", err)
+	}
+
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	return filename
+}
+
+func TestCountFileDecompress(t *testing.T) {
+	content, err := os.ReadFile("testdata/words.txt")
+	if err != nil {
+		t.Fatal("failed to read file:", err)
+	}
+
+	dname := t.TempDir()
+	gzipped := writeCompressed(t, dname, "words.txt.gz", content, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	zlibbed := writeCompressed(t, dname, "words.txt.zz", content, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+
+	// concatenated gzip members are read as a single stream, like zcat does
+	half := len(content) / 2
+	concatenated := filepath.Join(dname, "concatenated.gz")
+	first := writeCompressed(t, dname, "first.gz", content[:half], func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	second := writeCompressed(t, dname, "second.gz", content[half:], func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	firstData, _ := os.ReadFile(first)
+	secondData, _ := os.ReadFile(second)
+	if err := os.WriteFile(concatenated, append(firstData, secondData...), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		plain    bool
+	}{
+		{name: "plain", filename: "testdata/words.txt", plain: true},
+		{name: "gzip", filename: gzipped},
+		{name: "concatenated gzip", filename: concatenated},
+		{name: "zlib", filename: zlibbed},
+		{name: "bzip2", filename: "testdata/words.txt.bz2"},
+		{name: "compress", filename: "testdata/words.txt.Z"},
+		{name: "compress with 9 bit codes", filename: "testdata/words-b9.txt.Z"},
+		{name: "compress without clear codes", filename: "testdata/words-noblock.txt.Z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := os.Stat(tc.filename)
+			if err != nil {
+				t.Fatal("failed to stat file:", err)
+			}
+
+			// files that are not compressed have no compressed size
+			wants := GetCounts(bytes.NewReader(content))
+			if !tc.plain {
+				wants.compressedBytes = uint(info.Size())
+			}
+
+			got, err := CountFileWith(context.Background(), tc.filename, FileOptions{Decompress: true})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got)
+		})
+	}
+}
+
+func TestCountFileDecompressCorrupt(t *testing.T) {
+	compressed, err := os.ReadFile("testdata/words.txt.Z")
+	if err != nil {
+		t.Fatal("failed to read file:", err)
+	}
+
+	dname := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "truncated gzip", content: []byte{0x1f, 0x8b, 0x08, 0x00}},
+		{name: "truncated compress header", content: []byte{0x1f, 0x9d}},
+		{name: "compress code width too large", content: []byte{0x1f, 0x9d, 0x80 | 17, 0x00}},
+		{name: "compress first code not a byte", content: []byte{0x1f, 0x9d, 0x90, 0xff, 0x01}},
+		{name: "compress code not defined yet", content: append(bytes.Clone(compressed[:5]), 0xff, 0xff, 0xff)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(dname, strings.ReplaceAll(tc.name, " ", "-"))
+			if err := os.WriteFile(filename, tc.content, 0o644); err != nil {
+				t.Fatal("failed to create file:", err)
+			}
+
+			_, err := CountFileWith(context.Background(), filename, FileOptions{Decompress: true})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if !strings.HasPrefix(err.Error(), filename+": ") {
+				t.Errorf("expected the error to hold the filename, got %q", err)
+			}
+		})
+	}
+}
+
+func TestDecompressReaderSniffing(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "single byte", input: "x"},
+		{name: "text starting like zlib", input: "xy and more text\n"},
+		{name: "text starting like bzip2", input: "BZh? not really\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := decompressReader(bufio.NewReader(strings.NewReader(tc.input)))
+			assert.Equal(t, nil, err)
+
+			got, err := io.ReadAll(r)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.input, string(got))
+		})
+	}
+}
+
+func TestUnixCompressReaderSplitReads(t *testing.T) {
+	content, err := os.ReadFile("testdata/words.txt")
+	if err != nil {
+		t.Fatal("failed to read file:", err)
+	}
+
+	compressed, err := os.ReadFile("testdata/words-b9.txt.Z")
+	if err != nil {
+		t.Fatal("failed to read file:", err)
+	}
+
+	r, err := newUnixCompressReader(bytes.NewReader(compressed))
+	assert.Equal(t, nil, err)
+
+	// reads smaller than the strings the codes decode to
+	got := []byte{}
+	buf := make([]byte, 3)
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		assert.Equal(t, nil, err)
+	}
+
+	assert.Equal(t, string(content), string(got))
+}
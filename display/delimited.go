@@ -0,0 +1,59 @@
+package display
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// DelimitedFormatter writes counts as comma or tab separated values. The
+// header row is always written and the columns follow the same selection and
+// order as the table output, followed by the stats when shown and the
+// filename last
+type DelimitedFormatter struct {
+	w    *csv.Writer
+	opts Options
+}
+
+func NewCSVFormatter(w io.Writer, opts Options) Formatter {
+	return newDelimitedFormatter(w, opts, ',')
+}
+
+func NewTSVFormatter(w io.Writer, opts Options) Formatter {
+	return newDelimitedFormatter(w, opts, '\t')
+}
+
+func newDelimitedFormatter(w io.Writer, opts Options, separator rune) *DelimitedFormatter {
+	cw := csv.NewWriter(w)
+	cw.Comma = separator
+
+	return &DelimitedFormatter{
+		w:    cw,
+		opts: opts,
+	}
+}
+
+func (d *DelimitedFormatter) Header() error {
+	columns := append(d.opts.Columns(), d.opts.statsColumns()...)
+	return d.w.Write(append(columns, "filename"))
+}
+
+// Row writes the values of a single input. Filenames containing the separator,
+// quotes or line breaks are quoted
+func (d *DelimitedFormatter) Row(filename string, values Values) error {
+	row := append(d.opts.Row(values), d.opts.statsRow(values)...)
+	return d.w.Write(append(row, filename))
+}
+
+func (d *DelimitedFormatter) Total(values Values) error {
+	return d.Row("total", values)
+}
+
+// Errors are left for the caller to report, they have no place in the table
+func (d *DelimitedFormatter) Error(filename string, err error) error {
+	return nil
+}
+
+func (d *DelimitedFormatter) Finish() error {
+	d.w.Flush()
+	return d.w.Error()
+}
@@ -3,6 +3,7 @@ package display
 import (
 	"fmt"
 	"io"
+	"strconv"
 )
 
 type Options struct {
@@ -12,8 +13,17 @@ type Options struct {
 type NewOptionsArgs struct {
 	ShowLines  bool
 	ShowWords  bool
+	ShowChars  bool
 	ShowBytes  bool
 	ShowHeader bool
+
+	ShowMaxLineLength bool
+	// ShowCompressedBytes adds the size of the inputs before decompressing
+	// them after the other columns
+	ShowCompressedBytes bool
+	// ShowStats shows the distribution of the length of the lines and of the
+	// words in them
+	ShowStats bool
 }
 
 func NewOptions(args NewOptionsArgs) Options {
@@ -22,35 +32,102 @@ func NewOptions(args NewOptionsArgs) Options {
 	}
 }
 
-func (opts Options) shouldShowAll() bool {
-	return opts.args.ShowLines == opts.args.ShowWords && opts.args.ShowWords == opts.args.ShowBytes
+// shouldShowDefault reports whether no column was explicitly selected, in which
+// case lines, words and bytes are shown just like wc does
+func (opts Options) shouldShowDefault() bool {
+	args := opts.args
+	return !args.ShowLines && !args.ShowWords && !args.ShowChars && !args.ShowBytes && !args.ShowMaxLineLength
 }
 
 func (opts Options) ShouldShowLines() bool {
-	return opts.args.ShowLines || opts.shouldShowAll()
+	return opts.args.ShowLines || opts.shouldShowDefault()
 }
 
 func (opts Options) ShouldShowWords() bool {
-	return opts.args.ShowWords || opts.shouldShowAll()
+	return opts.args.ShowWords || opts.shouldShowDefault()
+}
+
+// Characters are only shown when explicitly requested
+func (opts Options) ShouldShowChars() bool {
+	return opts.args.ShowChars
 }
 
 func (opts Options) ShouldShowBytes() bool {
-	return opts.args.ShowBytes || opts.shouldShowAll()
+	return opts.args.ShowBytes || opts.shouldShowDefault()
 }
 
-func (opts Options) PrintHeader(w io.Writer) {
-	if !opts.args.ShowHeader {
-		return
+// The max line length is only shown when explicitly requested
+func (opts Options) ShouldShowMaxLineLength() bool {
+	return opts.args.ShowMaxLineLength
+}
+
+// The compressed size is shown next to the other columns, it doesn't replace
+// the default ones
+func (opts Options) ShouldShowCompressedBytes() bool {
+	return opts.args.ShowCompressedBytes
+}
+
+// Columns returns the header of every column that should be shown, in the same
+// order as their values are printed
+func (opts Options) Columns() []string {
+	columns := []string{}
+
+	if opts.ShouldShowLines() {
+		columns = append(columns, "lines")
+	}
+	if opts.ShouldShowWords() {
+		columns = append(columns, "words")
+	}
+	if opts.ShouldShowChars() {
+		columns = append(columns, "characters")
+	}
+	if opts.ShouldShowBytes() {
+		columns = append(columns, "bytes")
 	}
+	if opts.ShouldShowMaxLineLength() {
+		columns = append(columns, "max line length")
+	}
+	if opts.ShouldShowCompressedBytes() {
+		columns = append(columns, "compressed bytes")
+	}
+
+	return columns
+}
+
+// Row returns the values of every column that should be shown, in the same
+// order as Columns
+func (opts Options) Row(values Values) []string {
+	row := []string{}
 
 	if opts.ShouldShowLines() {
-		fmt.Fprintf(w, "lines\t")
+		row = append(row, strconv.FormatUint(uint64(values.Lines), 10))
 	}
 	if opts.ShouldShowWords() {
-		fmt.Fprintf(w, "words\t")
+		row = append(row, strconv.FormatUint(uint64(values.Words), 10))
+	}
+	if opts.ShouldShowChars() {
+		row = append(row, strconv.FormatUint(uint64(values.Chars), 10))
 	}
 	if opts.ShouldShowBytes() {
-		fmt.Fprintf(w, "characters\t")
+		row = append(row, strconv.FormatUint(uint64(values.Bytes), 10))
+	}
+	if opts.ShouldShowMaxLineLength() {
+		row = append(row, strconv.FormatUint(uint64(values.MaxLineLength), 10))
+	}
+	if opts.ShouldShowCompressedBytes() {
+		row = append(row, strconv.FormatUint(uint64(values.CompressedBytes), 10))
+	}
+
+	return row
+}
+
+func (opts Options) PrintHeader(w io.Writer) {
+	if !opts.args.ShowHeader {
+		return
+	}
+
+	for _, column := range opts.Columns() {
+		fmt.Fprintf(w, "%s\t", column)
 	}
 
 	fmt.Fprintln(w)
@@ -2,6 +2,8 @@ package display_test
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"testing"
 
 	"bloom.io/github.com/FerDev12/wc-go/display"
@@ -28,7 +30,7 @@ func TestPrintHeader(t *testing.T) {
 					ShowHeader: true,
 				}),
 			},
-			wants: "lines\twords\tcharacters\t\n",
+			wants: "lines\twords\tbytes\t\n",
 		},
 		{
 			name: "show lines with header",
@@ -64,7 +66,7 @@ func TestPrintHeader(t *testing.T) {
 					ShowHeader: true,
 				}),
 			},
-			wants: "characters\t\n",
+			wants: "bytes\t\n",
 		},
 		{
 			name: "show lines and words with header",
@@ -88,7 +90,7 @@ func TestPrintHeader(t *testing.T) {
 					ShowHeader: true,
 				}),
 			},
-			wants: "lines\tcharacters\t\n",
+			wants: "lines\tbytes\t\n",
 		},
 		{
 			name: "show words and bytes with header",
@@ -100,7 +102,57 @@ func TestPrintHeader(t *testing.T) {
 					ShowHeader: true,
 				}),
 			},
-			wants: "words\tcharacters\t\n",
+			wants: "words\tbytes\t\n",
+		},
+		{
+			name: "show chars with header",
+			input: inputs{
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowChars:  true,
+					ShowHeader: true,
+				}),
+			},
+			wants: "characters\t\n",
+		},
+		{
+			name: "show chars and bytes with header",
+			input: inputs{
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowChars:  true,
+					ShowBytes:  true,
+					ShowHeader: true,
+				}),
+			},
+			wants: "characters\tbytes\t\n",
+		},
+		{
+			name: "default columns with header",
+			input: inputs{
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowHeader: true,
+				}),
+			},
+			wants: "lines\twords\tbytes\t\n",
+		},
+		{
+			name: "show max line length with header",
+			input: inputs{
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowMaxLineLength: true,
+					ShowHeader:        true,
+				}),
+			},
+			wants: "max line length\t\n",
+		},
+		{
+			name: "compressed bytes with header",
+			input: inputs{
+				options: display.NewOptions(display.NewOptionsArgs{
+					ShowCompressedBytes: true,
+					ShowHeader:          true,
+				}),
+			},
+			wants: "lines\twords\tbytes\tcompressed bytes\t\n",
 		},
 	}
 
@@ -112,3 +164,305 @@ func TestPrintHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintJSON(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input display.Report
+		wants string
+	}{
+		{
+			name:  "no files",
+			input: display.Report{},
+			wants: `{
+  "files": [],
+  "total": {
+    "lines": 0,
+    "words": 0,
+    "chars": 0,
+    "bytes": 0,
+    "max_line_length": 0
+  }
+}
+`,
+		},
+		{
+			name: "file and error",
+			input: display.Report{
+				Files: []display.Record{
+					{
+						Filename: "words.txt",
+						Values:   &display.Values{Lines: 1, Words: 5, Chars: 24, Bytes: 24, MaxLineLength: 23},
+					},
+					{
+						Filename: "missing.txt",
+						Error:    "open missing.txt: no such file or directory",
+					},
+				},
+				Total: display.Values{Lines: 1, Words: 5, Chars: 24, Bytes: 24, MaxLineLength: 23},
+			},
+			wants: `{
+  "files": [
+    {
+      "filename": "words.txt",
+      "lines": 1,
+      "words": 5,
+      "chars": 24,
+      "bytes": 24,
+      "max_line_length": 23
+    },
+    {
+      "filename": "missing.txt",
+      "error": "open missing.txt: no such file or directory"
+    }
+  ],
+  "total": {
+    "lines": 1,
+    "words": 5,
+    "chars": 24,
+    "bytes": 24,
+    "max_line_length": 23
+  }
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			err := display.PrintJSON(&buffer, tc.input)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.wants, buffer.String())
+		})
+	}
+}
+
+func TestDelimitedFormatter(t *testing.T) {
+	values := display.Values{Lines: 1, Words: 5, Chars: 23, Bytes: 24, MaxLineLength: 22}
+
+	testCases := []struct {
+		name     string
+		tsv      bool
+		options  display.Options
+		filename string
+		wants    string
+	}{
+		{
+			name:     "csv default columns",
+			options:  display.NewOptions(display.NewOptionsArgs{}),
+			filename: "words.txt",
+			wants:    "lines,words,bytes,filename\n1,5,24,words.txt\n1,5,24,total\n",
+		},
+		{
+			name:     "csv selected columns",
+			options:  display.NewOptions(display.NewOptionsArgs{ShowChars: true, ShowMaxLineLength: true}),
+			filename: "words.txt",
+			wants:    "characters,max line length,filename\n23,22,words.txt\n23,22,total\n",
+		},
+		{
+			name:     "csv filename with comma",
+			options:  display.NewOptions(display.NewOptionsArgs{ShowLines: true}),
+			filename: "a,b.txt",
+			wants:    "lines,filename\n1,\"a,b.txt\"\n1,total\n",
+		},
+		{
+			name:     "csv filename with quotes",
+			options:  display.NewOptions(display.NewOptionsArgs{ShowLines: true}),
+			filename: `say "hi".txt`,
+			wants:    "lines,filename\n1,\"say \"\"hi\"\".txt\"\n1,total\n",
+		},
+		{
+			name:     "csv compressed bytes",
+			options:  display.NewOptions(display.NewOptionsArgs{ShowLines: true, ShowCompressedBytes: true}),
+			filename: "words.txt.gz",
+			wants:    "lines,compressed bytes,filename\n1,0,words.txt.gz\n1,0,total\n",
+		},
+		{
+			name:     "csv stats",
+			options:  display.NewOptions(display.NewOptionsArgs{ShowLines: true, ShowStats: true}),
+			filename: "words.txt",
+			wants: "lines," +
+				"line bytes min,line bytes max,line bytes mean,line bytes median,line bytes p95,line bytes p99," +
+				"line runes min,line runes max,line runes mean,line runes median,line runes p95,line runes p99," +
+				"line words min,line words max,line words mean,line words median,line words p95,line words p99,filename\n" +
+				"1,,,,,,,,,,,,,,,,,,,words.txt\n1,,,,,,,,,,,,,,,,,,,total\n",
+		},
+		{
+			name:     "tsv default columns",
+			tsv:      true,
+			options:  display.NewOptions(display.NewOptionsArgs{}),
+			filename: "words.txt",
+			wants:    "lines\twords\tbytes\tfilename\n1\t5\t24\twords.txt\n1\t5\t24\ttotal\n",
+		},
+		{
+			name:     "tsv filename with comma is not quoted",
+			tsv:      true,
+			options:  display.NewOptions(display.NewOptionsArgs{ShowWords: true}),
+			filename: "a,b.txt",
+			wants:    "words\tfilename\n5\ta,b.txt\n5\ttotal\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+
+			formatter := display.NewCSVFormatter(&buffer, tc.options)
+			if tc.tsv {
+				formatter = display.NewTSVFormatter(&buffer, tc.options)
+			}
+
+			assert.Equal(t, nil, formatter.Header())
+			assert.Equal(t, nil, formatter.Row(tc.filename, values))
+			assert.Equal(t, nil, formatter.Error("missing.txt", errors.New("not found")))
+			assert.Equal(t, nil, formatter.Total(values))
+			assert.Equal(t, nil, formatter.Finish())
+			assert.Equal(t, tc.wants, buffer.String())
+		})
+	}
+}
+
+func TestTableFormatter(t *testing.T) {
+	stats := display.StatsValues{
+		LineBytes: display.Summary{Min: 3, Max: 10, Mean: 6.5, Median: 3, P95: 10, P99: 10},
+		LineRunes: display.Summary{Min: 3, Max: 9, Mean: 6, Median: 3, P95: 9, P99: 9},
+		LineWords: display.Summary{Min: 1, Max: 2, Mean: 1.5, Median: 1, P95: 2, P99: 2},
+	}
+
+	type row struct {
+		filename string
+		values   display.Values
+	}
+
+	testCases := []struct {
+		name    string
+		options display.Options
+		rows    []row
+		total   display.Values
+		wants   string
+	}{
+		{
+			name:    "single file",
+			options: display.NewOptions(display.NewOptionsArgs{}),
+			rows: []row{
+				{filename: "words.txt", values: display.Values{Lines: 1, Words: 5, Bytes: 24}},
+			},
+			total: display.Values{Lines: 1, Words: 5, Bytes: 24},
+			wants: "    1    5    24 words.txt\n    1    5    24 total\n",
+		},
+		{
+			name:    "multiple files with header",
+			options: display.NewOptions(display.NewOptionsArgs{ShowLines: true, ShowHeader: true}),
+			rows: []row{
+				{filename: "a.txt", values: display.Values{Lines: 1}},
+				{filename: "b.txt", values: display.Values{Lines: 120}},
+			},
+			total: display.Values{Lines: 121},
+			wants: "    lines\n        1 a.txt\n      120 b.txt\n      121 total\n",
+		},
+		{
+			name:    "stats",
+			options: display.NewOptions(display.NewOptionsArgs{ShowLines: true, ShowStats: true}),
+			rows: []row{
+				{filename: "a.txt", values: display.Values{Lines: 2, Stats: &stats}},
+			},
+			total: display.Values{Lines: 2, Stats: &stats},
+			wants: "    2 a.txt\n    2 total\n\n" +
+				"    min    max    mean    median    p95    p99\n" +
+				"      3     10    6.50         3     10     10 line bytes a.txt\n" +
+				"      3      9    6.00         3      9      9 line runes a.txt\n" +
+				"      1      2    1.50         1      2      2 line words a.txt\n" +
+				"      3     10    6.50         3     10     10 line bytes total\n" +
+				"      3      9    6.00         3      9      9 line runes total\n" +
+				"      1      2    1.50         1      2      2 line words total\n",
+		},
+		{
+			name:    "stdin has no total",
+			options: display.NewOptions(display.NewOptionsArgs{}),
+			rows: []row{
+				{values: display.Values{Lines: 1, Words: 3, Bytes: 14}},
+			},
+			total: display.Values{Lines: 1, Words: 3, Bytes: 14},
+			wants: "    1    3    14\n",
+		},
+	}
+
+	t.Run("failed stdin has no total", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+		formatter := display.NewTableFormatter(&buffer, display.NewOptions(display.NewOptionsArgs{}))
+
+		assert.Equal(t, nil, formatter.Header())
+		assert.Equal(t, nil, formatter.Error("", errors.New("interrupted")))
+		assert.Equal(t, nil, formatter.Total(display.Values{}))
+		assert.Equal(t, nil, formatter.Finish())
+		assert.Equal(t, "", buffer.String())
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			formatter := display.NewTableFormatter(&buffer, tc.options)
+
+			assert.Equal(t, nil, formatter.Header())
+			for _, r := range tc.rows {
+				assert.Equal(t, nil, formatter.Row(r.filename, r.values))
+			}
+			assert.Equal(t, nil, formatter.Total(tc.total))
+			assert.Equal(t, nil, formatter.Finish())
+			assert.Equal(t, tc.wants, buffer.String())
+		})
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	buffer := bytes.Buffer{}
+	formatter := display.NewJSONFormatter(&buffer, display.NewOptions(display.NewOptionsArgs{}))
+
+	values := display.Values{Lines: 1, Words: 5, Chars: 24, Bytes: 24, MaxLineLength: 23}
+
+	assert.Equal(t, nil, formatter.Header())
+	assert.Equal(t, nil, formatter.Row("words.txt", values))
+	assert.Equal(t, nil, formatter.Error("missing.txt", errors.New("not found")))
+	assert.Equal(t, nil, formatter.Total(values))
+	assert.Equal(t, nil, formatter.Finish())
+
+	wants := bytes.Buffer{}
+	display.PrintJSON(&wants, display.Report{
+		Files: []display.Record{
+			{Filename: "words.txt", Values: &values},
+			{Filename: "missing.txt", Error: "not found"},
+		},
+		Total: values,
+	})
+
+	assert.Equal(t, wants.String(), buffer.String())
+}
+
+type countingFormatter struct {
+	rows int
+}
+
+func (c *countingFormatter) Header() error                          { return nil }
+func (c *countingFormatter) Row(string, display.Values) error       { c.rows++; return nil }
+func (c *countingFormatter) Total(display.Values) error             { return nil }
+func (c *countingFormatter) Error(filename string, err error) error { return nil }
+func (c *countingFormatter) Finish() error                          { return nil }
+
+func TestRegisterFormatter(t *testing.T) {
+	_, err := display.NewFormatter("counting", io.Discard, display.Options{})
+	assert.Equal(t, `unknown format "counting"`, err.Error())
+
+	registered := &countingFormatter{}
+	display.RegisterFormatter("counting", func(w io.Writer, opts display.Options) display.Formatter {
+		return registered
+	})
+
+	formatter, err := display.NewFormatter("counting", io.Discard, display.Options{})
+	assert.Equal(t, nil, err)
+
+	formatter.Row("a.txt", display.Values{})
+	assert.Equal(t, 1, registered.rows)
+
+	assert.Equal(t, []string{"counting", "csv", "json", "table", "tsv"}, display.Formatters())
+}
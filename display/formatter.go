@@ -0,0 +1,70 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Formatter renders the counts of every input. Header is called once before
+// any row, then Row or Error once per input in order, Total once with the
+// aggregated values and finally Finish, which must flush any buffered output
+type Formatter interface {
+	Header() error
+	Row(filename string, values Values) error
+	Total(values Values) error
+	Error(filename string, err error) error
+	Finish() error
+}
+
+// NewFormatterFunc creates a formatter writing to w
+type NewFormatterFunc func(w io.Writer, opts Options) Formatter
+
+const DEFAULT_FORMAT = "table"
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]NewFormatterFunc{
+		DEFAULT_FORMAT: NewTableFormatter,
+		"json":         NewJSONFormatter,
+		"csv":          NewCSVFormatter,
+		"tsv":          NewTSVFormatter,
+	}
+)
+
+// RegisterFormatter makes a formatter available by name, replacing any
+// formatter previously registered with the same name
+func RegisterFormatter(name string, fn NewFormatterFunc) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+
+	formatters[name] = fn
+}
+
+// NewFormatter creates the formatter registered under name
+func NewFormatter(name string, w io.Writer, opts Options) (Formatter, error) {
+	formattersMu.RLock()
+	fn, ok := formatters[name]
+	formattersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+
+	return fn(w, opts), nil
+}
+
+// Formatters returns the names of every registered formatter, sorted
+func Formatters() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
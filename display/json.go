@@ -0,0 +1,81 @@
+package display
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Values holds every count computed for an input in a form that can be
+// serialized
+type Values struct {
+	Lines         uint `json:"lines"`
+	Words         uint `json:"words"`
+	Chars         uint `json:"chars"`
+	Bytes         uint `json:"bytes"`
+	MaxLineLength uint `json:"max_line_length"`
+	// CompressedBytes is only set for decompressed inputs
+	CompressedBytes uint `json:"compressed_bytes,omitempty"`
+	// Stats is only set when the stats of the lines were collected
+	Stats *StatsValues `json:"stats,omitempty"`
+}
+
+// Record is the result of counting a single input. Values is nil when counting
+// failed, in which case Error holds the reason
+type Record struct {
+	Filename string `json:"filename,omitempty"`
+	*Values
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the full JSON document written by the CLI
+type Report struct {
+	Files []Record `json:"files"`
+	Total Values   `json:"total"`
+}
+
+func PrintJSON(w io.Writer, report Report) error {
+	if report.Files == nil {
+		report.Files = []Record{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+// JSONFormatter collects every record and writes them as a single Report
+// document when finished
+type JSONFormatter struct {
+	w      io.Writer
+	report Report
+}
+
+func NewJSONFormatter(w io.Writer, opts Options) Formatter {
+	return &JSONFormatter{
+		w: w,
+	}
+}
+
+func (j *JSONFormatter) Header() error {
+	return nil
+}
+
+func (j *JSONFormatter) Row(filename string, values Values) error {
+	j.report.Files = append(j.report.Files, Record{Filename: filename, Values: &values})
+	return nil
+}
+
+func (j *JSONFormatter) Total(values Values) error {
+	j.report.Total = values
+	return nil
+}
+
+func (j *JSONFormatter) Error(filename string, err error) error {
+	j.report.Files = append(j.report.Files, Record{Filename: filename, Error: err.Error()})
+	return nil
+}
+
+func (j *JSONFormatter) Finish() error {
+	return PrintJSON(j.w, j.report)
+}
@@ -0,0 +1,122 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// STATS_FIGURES are the figures shown for every measure of the lines, in order
+var STATS_FIGURES = []string{"min", "max", "mean", "median", "p95", "p99"}
+
+// Summary holds the figures shown for the distribution of a measure
+type Summary struct {
+	Min    uint    `json:"min"`
+	Max    uint    `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median uint    `json:"median"`
+	P95    uint    `json:"p95"`
+	P99    uint    `json:"p99"`
+}
+
+// cells formats the figures in the same order as STATS_FIGURES
+func (s Summary) cells() []string {
+	return []string{
+		strconv.FormatUint(uint64(s.Min), 10),
+		strconv.FormatUint(uint64(s.Max), 10),
+		strconv.FormatFloat(s.Mean, 'f', 2, 64),
+		strconv.FormatUint(uint64(s.Median), 10),
+		strconv.FormatUint(uint64(s.P95), 10),
+		strconv.FormatUint(uint64(s.P99), 10),
+	}
+}
+
+// StatsValues describes the lines of an input: their length in bytes and in
+// runes and the number of words in them
+type StatsValues struct {
+	LineBytes Summary `json:"line_bytes"`
+	LineRunes Summary `json:"line_runes"`
+	LineWords Summary `json:"line_words"`
+}
+
+type measure struct {
+	name    string
+	summary Summary
+}
+
+// measures returns every measure with its name, in the order they are shown
+func (s StatsValues) measures() []measure {
+	return []measure{
+		{name: "line bytes", summary: s.LineBytes},
+		{name: "line runes", summary: s.LineRunes},
+		{name: "line words", summary: s.LineWords},
+	}
+}
+
+// The stats are only shown when explicitly requested
+func (opts Options) ShouldShowStats() bool {
+	return opts.args.ShowStats
+}
+
+// statsColumns returns a column per figure of every measure, for the formats
+// that show the stats next to the counts
+func (opts Options) statsColumns() []string {
+	columns := []string{}
+
+	if !opts.ShouldShowStats() {
+		return columns
+	}
+
+	for _, m := range (StatsValues{}).measures() {
+		for _, figure := range STATS_FIGURES {
+			columns = append(columns, m.name+" "+figure)
+		}
+	}
+
+	return columns
+}
+
+// statsRow returns the values of statsColumns. Inputs without stats leave
+// them empty
+func (opts Options) statsRow(values Values) []string {
+	if !opts.ShouldShowStats() || values.Stats == nil {
+		return make([]string, len(opts.statsColumns()))
+	}
+
+	row := []string{}
+
+	for _, m := range values.Stats.measures() {
+		row = append(row, m.summary.cells()...)
+	}
+
+	return row
+}
+
+type namedStats struct {
+	name  string
+	stats StatsValues
+}
+
+// printStats prints the stats of every input as a table of its own, with a
+// row per measure
+func printStats(w io.Writer, stats []namedStats) error {
+	tw := tabwriter.NewWriter(w, 0, TAB_WIDTH, PADDING, PAD_CHAR, TAB_FLAG)
+
+	fmt.Fprintf(tw, "%s\t\n", strings.Join(STATS_FIGURES, "\t"))
+
+	for _, s := range stats {
+		for _, m := range s.stats.measures() {
+			fmt.Fprintf(tw, "%s\t %s", strings.Join(m.summary.cells(), "\t"), m.name)
+
+			if s.name != "" {
+				fmt.Fprintf(tw, " %s", s.name)
+			}
+
+			fmt.Fprintln(tw)
+		}
+	}
+
+	return tw.Flush()
+}
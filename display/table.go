@@ -0,0 +1,108 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const TAB_WIDTH = 8
+const PADDING = 4
+const PAD_CHAR = ' '
+const TAB_FLAG = tabwriter.AlignRight
+
+// TableFormatter aligns the counts in right aligned columns, the same way wc
+// does. The stats, when shown, follow in a table of their own. Errors are
+// left for the caller to report
+type TableFormatter struct {
+	out     io.Writer
+	w       *tabwriter.Writer
+	opts    Options
+	rows    int
+	unnamed bool
+	stats   []namedStats
+}
+
+func NewTableFormatter(w io.Writer, opts Options) Formatter {
+	return &TableFormatter{
+		out:  w,
+		w:    tabwriter.NewWriter(w, 0, TAB_WIDTH, PADDING, PAD_CHAR, TAB_FLAG),
+		opts: opts,
+	}
+}
+
+// addStats keeps the stats of an input to print them once the counts are
+func (t *TableFormatter) addStats(name string, values Values) {
+	if t.opts.ShouldShowStats() && values.Stats != nil {
+		t.stats = append(t.stats, namedStats{name: name, stats: *values.Stats})
+	}
+}
+
+func (t *TableFormatter) Header() error {
+	t.opts.PrintHeader(t.w)
+	return nil
+}
+
+func (t *TableFormatter) Row(filename string, values Values) error {
+	t.rows++
+	t.unnamed = filename == ""
+
+	if filename == "" {
+		t.opts.PrintRow(t.w, values)
+	} else {
+		t.opts.PrintRow(t.w, values, filename)
+	}
+
+	t.addStats(filename, values)
+
+	return nil
+}
+
+// Total prints the totals unless the only input was read from stdin
+func (t *TableFormatter) Total(values Values) error {
+	if t.rows == 1 && t.unnamed {
+		return nil
+	}
+
+	t.opts.PrintRow(t.w, values, "total")
+	t.addStats("total", values)
+
+	return nil
+}
+
+func (t *TableFormatter) Error(filename string, err error) error {
+	t.rows++
+	t.unnamed = filename == ""
+
+	return nil
+}
+
+func (t *TableFormatter) Finish() error {
+	if err := t.w.Flush(); err != nil {
+		return err
+	}
+
+	if len(t.stats) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(t.out)
+
+	return printStats(t.out, t.stats)
+}
+
+// PrintRow prints the selected values as tab terminated cells followed by the
+// suffixes
+func (opts Options) PrintRow(w io.Writer, values Values, suffixes ...string) {
+	line := strings.Join(opts.Row(values), "\t") + "\t"
+	suffixStr := strings.Join(suffixes, " ")
+
+	fmt.Fprintf(w, "%s", line)
+
+	if suffixStr != "" {
+		fmt.Fprintf(w, " %s", suffixStr)
+	}
+
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,371 @@
+package counter
+
+import (
+	"bytes"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BLOCK_SIZE is the size of the reads done by the block engine
+const BLOCK_SIZE = 128 * 1024
+
+// Flags describing how every ASCII byte affects the counts
+const (
+	classSpace = 1 << iota
+	// classBreak bytes end the current line for the max line length
+	classBreak
+	classTab
+	// classPrint bytes take up a single column
+	classPrint
+)
+
+var asciiClass = func() [utf8.RuneSelf]uint8 {
+	table := [utf8.RuneSelf]uint8{}
+
+	for b := range utf8.RuneSelf {
+		if unicode.IsSpace(rune(b)) {
+			table[b] |= classSpace
+		}
+		if runeWidth(rune(b)) == 1 {
+			table[b] |= classPrint
+		}
+	}
+
+	table['\n'] |= classBreak
+	table['\r'] |= classBreak
+	table['\f'] |= classBreak
+	table['\t'] |= classTab
+
+	return table
+}()
+
+// scanner counts a stream that is handed to it in blocks of any size. All of
+// the state needed to continue counting is kept between blocks, including
+// UTF-8 sequences split across them
+type scanner struct {
+	// what selects the counts to compute and counting the ones the scanner
+	// computes itself, which leaves out the words when a word counter finds
+	// them
+	what     CountOptions
+	counting CountOptions
+	words    WordCounter
+	stats    *lineStats
+	counts   Counts
+	inWord   bool
+	// col is the display width of the current line so far
+	col uint
+	// pending holds the start of a UTF-8 sequence cut at the end of a block
+	pending  [utf8.UTFMax]byte
+	npending int
+
+	// head describes the text before the first line break, which is needed to
+	// merge the counts of consecutive chunks
+	head     lineHead
+	sawBreak bool
+}
+
+// newScanner returns a scanner computing the selected counts, zero selects
+// every count. Words are found by the scanner itself for the whitespace rule
+// and by the rule's own counter for any other
+func newScanner(what CountOptions, rule WordRule) *scanner {
+	what = what.orAll()
+	s := &scanner{what: what, counting: what}
+
+	if what&COUNT_WORDS != 0 && !isWhitespace(rule) {
+		s.words = rule.NewWordCounter()
+		s.counting &^= COUNT_WORDS
+	}
+
+	if what&COUNT_STATS != 0 {
+		s.stats = newLineStats(rule)
+	}
+
+	return s
+}
+
+// lineHead describes the start of a chunk up to its first line break, measured
+// as if it started on column 0
+type lineHead struct {
+	// end is the column reached at the first line break or the end of the chunk
+	end uint
+	// tab is set when there is a tab, tabCol is the column it was found at
+	tab    bool
+	tabCol uint
+}
+
+// column returns the column the head ends at when the chunk starts at col.
+// Without tabs the width is just shifted, otherwise everything after the first
+// tab is aligned to the same tab stop no matter where it started
+func (h lineHead) column(col uint) uint {
+	if !h.tab {
+		return col + h.end
+	}
+
+	firstStop := h.tabCol - h.tabCol%TAB_STOP + TAB_STOP
+	shiftedStop := (col + h.tabCol) - (col+h.tabCol)%TAB_STOP + TAB_STOP
+
+	return shiftedStop + h.end - firstStop
+}
+
+// write counts the block doing as little work as the selected counts allow
+func (s *scanner) write(p []byte) {
+	if s.words != nil {
+		s.words.Write(p)
+	}
+	if s.stats != nil {
+		s.stats.write(p)
+	}
+
+	s.count(p)
+}
+
+// count computes the counts of the block the scanner finds itself
+func (s *scanner) count(p []byte) {
+	switch {
+	case !s.counting.needsDecoding():
+		s.counts.lines += uint(bytes.Count(p, []byte{'\n'}))
+		s.counts.bytes += uint(len(p))
+	case !s.counting.needsClasses():
+		s.writeChars(p)
+	default:
+		s.writeAll(p)
+	}
+}
+
+// writeChars counts lines, characters and bytes. Only the start of every non
+// ASCII rune is decoded, nothing is classified
+func (s *scanner) writeChars(p []byte) {
+	if s.npending > 0 {
+		p = s.completePending(p)
+		if p == nil {
+			return
+		}
+	}
+
+	s.counts.lines += uint(bytes.Count(p, []byte{'\n'}))
+	s.counts.bytes += uint(len(p))
+
+	chars := s.counts.chars
+
+	for i := 0; i < len(p); {
+		if p[i] < utf8.RuneSelf {
+			chars++
+			i++
+			continue
+		}
+
+		if !utf8.FullRune(p[i:]) {
+			// the rest of the sequence comes in the next block
+			s.npending = copy(s.pending[:], p[i:])
+			s.counts.bytes -= uint(s.npending)
+			break
+		}
+
+		_, size := utf8.DecodeRune(p[i:])
+		chars++
+		i += size
+	}
+
+	s.counts.chars = chars
+}
+
+// writeAll counts everything in the block. ASCII bytes are classified with a
+// lookup table and only the rest is decoded as UTF-8
+func (s *scanner) writeAll(p []byte) {
+	if s.npending > 0 {
+		p = s.completePending(p)
+		if p == nil {
+			return
+		}
+	}
+
+	s.counts.lines += uint(bytes.Count(p, []byte{'\n'}))
+	s.counts.bytes += uint(len(p))
+
+	inWord := s.inWord
+	col := s.col
+	words := s.counts.words
+	chars := s.counts.chars
+	maxLineLength := s.counts.maxLineLength
+
+	for i := 0; i < len(p); {
+		b := p[i]
+
+		if b < utf8.RuneSelf {
+			class := asciiClass[b]
+			chars++
+			i++
+
+			// most bytes are printable characters that are not spaces
+			if class == classPrint {
+				if !inWord {
+					words++
+					inWord = true
+				}
+				col++
+				continue
+			}
+
+			if class&classSpace != 0 {
+				inWord = false
+			} else if !inWord {
+				words++
+				inWord = true
+			}
+
+			switch {
+			case class&classPrint != 0:
+				col++
+			case class&classBreak != 0:
+				if !s.sawBreak {
+					s.sawBreak = true
+					s.head.end = col
+				}
+				maxLineLength = max(maxLineLength, col)
+				col = 0
+			case class&classTab != 0:
+				if !s.sawBreak && !s.head.tab {
+					s.head.tab = true
+					s.head.tabCol = col
+				}
+				col += TAB_STOP - col%TAB_STOP
+			}
+
+			continue
+		}
+
+		if !utf8.FullRune(p[i:]) {
+			// the rest of the sequence comes in the next block
+			s.npending = copy(s.pending[:], p[i:])
+			s.counts.bytes -= uint(s.npending)
+			break
+		}
+
+		r, size := utf8.DecodeRune(p[i:])
+
+		isSpace := unicode.IsSpace(r)
+		if !isSpace && !inWord {
+			words++
+		}
+		inWord = !isSpace
+
+		col += runeWidth(r)
+		chars++
+		i += size
+	}
+
+	s.inWord = inWord
+	s.col = col
+	s.counts.words = words
+	s.counts.chars = chars
+	s.counts.maxLineLength = maxLineLength
+}
+
+// completePending finishes the UTF-8 sequence cut at the end of the previous
+// block with the start of p, and returns the rest of p. It returns nil when p
+// was not enough to complete it or was already counted
+func (s *scanner) completePending(p []byte) []byte {
+	n := copy(s.pending[s.npending:], p)
+	seq := s.pending[:s.npending+n]
+
+	if !utf8.FullRune(seq) {
+		s.npending = len(seq)
+		return nil
+	}
+
+	r, size := utf8.DecodeRune(seq)
+	s.countRune(r, size)
+
+	used := size - s.npending
+	if used < 0 {
+		// invalid sequence, only its first byte is consumed and the bytes after
+		// it have to be counted before p
+		rest := bytes.Clone(s.pending[size:s.npending])
+		s.npending = 0
+
+		s.count(rest)
+		s.count(p)
+		return nil
+	}
+
+	s.npending = 0
+
+	return p[used:]
+}
+
+// countRune counts a single non ASCII rune
+func (s *scanner) countRune(r rune, size int) {
+	isSpace := unicode.IsSpace(r)
+	if !isSpace && !s.inWord {
+		s.counts.words++
+	}
+	s.inWord = !isSpace
+
+	s.col += runeWidth(r)
+	s.counts.chars++
+	s.counts.bytes += uint(size)
+}
+
+// finish returns the counts once the whole stream has been written. Bytes of
+// an incomplete UTF-8 sequence at the end count as one invalid character each
+func (s *scanner) finish() Counts {
+	for range s.npending {
+		s.countRune(utf8.RuneError, 1)
+	}
+	s.npending = 0
+
+	if !s.sawBreak {
+		s.head.end = s.col
+	}
+
+	counts := s.counts
+	counts.maxLineLength = max(counts.maxLineLength, s.col)
+	counts = counts.only(s.what)
+
+	if s.words != nil {
+		counts.words = s.words.Words()
+	}
+	if s.stats != nil {
+		counts.stats = s.stats.finish()
+	}
+
+	return counts
+}
+
+// clone returns a copy of the scanner that can be finished without changing
+// it. The word counters of rules other than the built-in ones can't be
+// cloned, the copy shares them
+func (s *scanner) clone() *scanner {
+	c := *s
+	if words, ok := cloneWords(s.words); ok {
+		c.words = words
+	}
+	if s.stats != nil {
+		c.stats = s.stats.clone()
+	}
+
+	return &c
+}
+
+// readFrom writes everything in the reader to the scanner, in large blocks
+func (s *scanner) readFrom(r io.Reader) error {
+	buf := make([]byte, BLOCK_SIZE)
+
+	for {
+		n, err := r.Read(buf)
+		s.write(buf[:n])
+
+		if err != nil {
+			return readErr(err)
+		}
+	}
+}
+
+// getCountsBlocks counts the reader in large blocks through the scanner
+func getCountsBlocks(r io.Reader, what CountOptions, rule WordRule) (Counts, error) {
+	s := newScanner(what, rule)
+	err := s.readFrom(r)
+
+	return s.finish(), err
+}
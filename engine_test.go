@@ -0,0 +1,122 @@
+package counter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+var engineInputs = []string{
+	"",
+	"one two three four five\n",
+	"	one two		three\n",
+	"one two three four five six",
+	"one two thrРee four five",
+	"日本語のテキスト\n二行目 です\n",
+	"emoji 😀😀 and\tmore 😀\n",
+	"é́ combining\n",
+	"invalid \xff\xfe bytes\n",
+	"truncated \xe2\x82",
+	"truncated then ascii \xe2\x82a b",
+	"\xe2\x82\xe2\x82\xac",
+	"\xf0\x9f\x98",
+	"a\x00b\x1b[0m c\r\nd\fe\vf\n",
+	"nbsp separated figure　ideographic",
+	"\u0085next line",
+	strings.Repeat("long line without breaks ", 50),
+}
+
+// writeSplit counts the input writing it to the scanner in pieces of size n
+func writeSplit(input string, n int) Counts {
+	s := newScanner(COUNT_ALL, nil)
+
+	for len(input) > 0 {
+		size := min(n, len(input))
+		s.write([]byte(input[:size]))
+		input = input[size:]
+	}
+
+	return s.finish()
+}
+
+func TestScannerMatchesSinglePass(t *testing.T) {
+	for _, input := range engineInputs {
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+		got, err := getCountsBlocks(strings.NewReader(input), COUNT_ALL, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, wants, got, "blocks", input)
+
+		for n := 1; n <= 5; n++ {
+			assert.Equal(t, wants, writeSplit(input, n), "split", input)
+		}
+
+		for i := range len(input) {
+			s := newScanner(COUNT_ALL, nil)
+			s.write([]byte(input[:i]))
+			s.write([]byte(input[i:]))
+			assert.Equal(t, wants, s.finish(), "two writes", input)
+		}
+	}
+}
+
+func TestGetCountsWith(t *testing.T) {
+	for _, input := range engineInputs {
+		all, _ := getCountsSinglePass(strings.NewReader(input))
+
+		for what := range COUNT_ALL + 1 {
+			wants := all.only(what)
+
+			got, err := GetCountsWith(strings.NewReader(input), what)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got, fmt.Sprintf("%05b", what), input)
+
+			for n := 1; n <= 3; n++ {
+				s := newScanner(what, nil)
+				for i := 0; i < len(input); i += n {
+					s.write([]byte(input[i:min(i+n, len(input))]))
+				}
+				assert.Equal(t, wants, s.finish(), "split", fmt.Sprintf("%05b", what), input)
+			}
+		}
+	}
+}
+
+func TestCountsOnly(t *testing.T) {
+	counts := Counts{lines: 1, words: 2, chars: 3, bytes: 4, maxLineLength: 5}
+
+	testCases := []struct {
+		name  string
+		what  CountOptions
+		wants Counts
+	}{
+		{name: "zero value", what: 0, wants: counts},
+		{name: "all", what: COUNT_ALL, wants: counts},
+		{name: "lines", what: COUNT_LINES, wants: Counts{lines: 1}},
+		{name: "words and bytes", what: COUNT_WORDS | COUNT_BYTES, wants: Counts{words: 2, bytes: 4}},
+		{name: "chars and max line length", what: COUNT_CHARS | COUNT_MAX_LINE_LENGTH, wants: Counts{chars: 3, maxLineLength: 5}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wants, counts.only(tc.what))
+		})
+	}
+}
+
+func FuzzScanner(f *testing.F) {
+	for _, input := range engineInputs {
+		f.Add(input, 3)
+	}
+
+	f.Fuzz(func(t *testing.T, input string, n int) {
+		if n <= 0 {
+			n = 1
+		}
+
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+		assert.Equal(t, wants, writeSplit(input, n))
+	})
+}
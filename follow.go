@@ -0,0 +1,168 @@
+package counter
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// DEFAULT_FOLLOW_INTERVAL is how often Follow checks a file for more data once
+// it reached its end, the same as tail -f
+const DEFAULT_FOLLOW_INTERVAL = time.Second
+
+type FollowOptions struct {
+	// What selects the counts to compute, zero counts everything. Words are
+	// separated by white space
+	What CountOptions
+	// Interval is how often the file is checked for more data, zero means
+	// DEFAULT_FOLLOW_INTERVAL
+	Interval time.Duration
+	// OnUpdate receives the counts once the file is first counted and every
+	// time more data was counted, or the counts were reset
+	OnUpdate func(Counts)
+}
+
+func (opts FollowOptions) interval() time.Duration {
+	if opts.Interval <= 0 {
+		return DEFAULT_FOLLOW_INTERVAL
+	}
+
+	return opts.Interval
+}
+
+// follower keeps the state of the file being followed
+type follower struct {
+	opts     FollowOptions
+	filename string
+	file     *os.File
+	// read is the number of bytes read from the current file, base holds the
+	// counts of the files that were rotated away
+	read    int64
+	base    Counts
+	counter *Counter
+	buf     []byte
+}
+
+// Follow counts the file and keeps counting whatever is appended to it, like
+// tail -f, until the context is cancelled. A file that shrinks was truncated
+// and is counted again from its start, dropping its previous counts. A file
+// replaced by another one at the same path was rotated: the rest of the old
+// file is counted and the counts go on with the new one. The counts when the
+// context was cancelled are returned along with its error, and failing to
+// read the file returns the counts up to that point and the read error
+func Follow(ctx context.Context, filename string, opts FollowOptions) (Counts, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Counts{}, err
+	}
+
+	f := &follower{
+		opts:     opts,
+		filename: filename,
+		file:     file,
+		counter:  NewCounter(opts.What),
+		buf:      make([]byte, BLOCK_SIZE),
+	}
+	defer func() { f.file.Close() }()
+
+	if _, err := f.readToEnd(); err != nil {
+		return f.counts(), err
+	}
+	f.update()
+
+	ticker := time.NewTicker(opts.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return f.counts(), ctx.Err()
+		case <-ticker.C:
+		}
+
+		changed, err := f.poll()
+		if err != nil {
+			return f.counts(), err
+		}
+
+		if changed {
+			f.update()
+		}
+	}
+}
+
+func (f *follower) counts() Counts {
+	return f.base.Add(f.counter.Counts())
+}
+
+func (f *follower) update() {
+	if f.opts.OnUpdate != nil {
+		f.opts.OnUpdate(f.counts())
+	}
+}
+
+// readToEnd counts the file from where it was left up to its current end and
+// reports whether there was anything to count
+func (f *follower) readToEnd() (bool, error) {
+	read := false
+
+	for {
+		n, err := f.file.Read(f.buf)
+		f.counter.Write(f.buf[:n])
+		f.read += int64(n)
+		read = read || n > 0
+
+		if err == io.EOF {
+			return read, nil
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+}
+
+// poll counts what was added to the file since the last time and handles it
+// being truncated or rotated. It reports whether the counts changed
+func (f *follower) poll() (bool, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	truncated := info.Mode().IsRegular() && info.Size() < f.read
+	if truncated {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+
+		f.read = 0
+		f.counter = NewCounter(f.opts.What)
+	}
+
+	changed, err := f.readToEnd()
+	if err != nil {
+		return truncated || changed, err
+	}
+
+	// a missing file is waited for, it's usually about to be created again
+	current, err := os.Stat(f.filename)
+	if err != nil || os.SameFile(info, current) {
+		return truncated || changed, nil
+	}
+
+	rotated, err := os.Open(f.filename)
+	if err != nil {
+		return truncated || changed, nil
+	}
+
+	f.file.Close()
+	f.file = rotated
+	f.read = 0
+	f.base = f.counts()
+	f.counter = NewCounter(f.opts.What)
+
+	read, err := f.readToEnd()
+
+	return truncated || changed || read, err
+}
@@ -0,0 +1,126 @@
+package counter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+// followFile follows the file in the background and returns the channel the
+// updates are sent to, along with the result once the context is cancelled
+func followFile(t *testing.T, ctx context.Context, filename string, what CountOptions) (<-chan Counts, <-chan error) {
+	t.Helper()
+
+	updates := make(chan Counts, 16)
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := Follow(ctx, filename, FollowOptions{
+			What:     what,
+			Interval: 5 * time.Millisecond,
+			OnUpdate: func(c Counts) { updates <- c },
+		})
+		done <- err
+	}()
+
+	return updates, done
+}
+
+func nextUpdate(t *testing.T, updates <-chan Counts) Counts {
+	t.Helper()
+
+	select {
+	case c := <-updates:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an update")
+		return Counts{}
+	}
+}
+
+func appendFile(t *testing.T, filename, content string) {
+	t.Helper()
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal("failed to open file:", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal("failed to write file:", err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, filename, "one two\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, done := followFile(t, ctx, filename, COUNT_LINES|COUNT_WORDS|COUNT_BYTES)
+
+	assert.Equal(t, Counts{lines: 1, words: 2, bytes: 8}, nextUpdate(t, updates), "first count")
+
+	// a word cut between two writes is counted once
+	appendFile(t, filename, "three fo")
+	assert.Equal(t, Counts{lines: 1, words: 4, bytes: 16}, nextUpdate(t, updates), "appended")
+
+	appendFile(t, filename, "ur\n")
+	assert.Equal(t, Counts{lines: 2, words: 4, bytes: 19}, nextUpdate(t, updates), "appended the rest")
+
+	t.Run("truncated", func(t *testing.T) {
+		if err := os.WriteFile(filename, []byte("x\n"), 0o644); err != nil {
+			t.Fatal("failed to truncate file:", err)
+		}
+
+		// the file may be seen empty before it's written again
+		c := nextUpdate(t, updates)
+		for c.bytes == 0 {
+			c = nextUpdate(t, updates)
+		}
+		assert.Equal(t, Counts{lines: 1, words: 1, bytes: 2}, c)
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		// the rest of the old file is counted before moving on to the new one
+		appendFile(t, filename, "y\n")
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			t.Fatal("failed to rotate file:", err)
+		}
+		appendFile(t, filename, "new file\n")
+
+		c := nextUpdate(t, updates)
+		for c.bytes < 13 {
+			c = nextUpdate(t, updates)
+		}
+		assert.Equal(t, Counts{lines: 3, words: 4, bytes: 13}, c)
+
+		appendFile(t, filename, "more\n")
+		assert.Equal(t, Counts{lines: 4, words: 5, bytes: 18}, nextUpdate(t, updates))
+	})
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestFollowMissingFile(t *testing.T) {
+	_, err := Follow(context.Background(), filepath.Join(t.TempDir(), "missing.log"), FollowOptions{})
+	assert.Equal(t, true, os.IsNotExist(err))
+}
+
+func TestFollowCancelled(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, filename, "one two\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := Follow(ctx, filename, FollowOptions{})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, GetCounts(strings.NewReader("one two\n")), got)
+}
@@ -0,0 +1,196 @@
+package gitignore
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const FILENAME = ".gitignore"
+
+type pattern struct {
+	// base is the directory holding the .gitignore file the pattern was read
+	// from, relative to the walked root and slash separated. Empty for the root
+	base string
+	// segments are the slash separated parts of the glob
+	segments []string
+	negate   bool
+	dirOnly  bool
+	// anchored patterns are matched against the whole path relative to base,
+	// the rest only against the last element of the path
+	anchored bool
+}
+
+// Matcher decides whether a path is ignored by the .gitignore files added to it.
+// Patterns added later take precedence over the ones added before, so files
+// must be added from the outermost directory inwards, which is the order in
+// which a directory walk finds them
+type Matcher struct {
+	patterns []pattern
+}
+
+func NewMatcher() *Matcher {
+	return &Matcher{}
+}
+
+// parsePattern parses a single .gitignore line. It returns false for blank
+// lines and comments
+func parsePattern(line string) (pattern, bool) {
+	p := pattern{}
+
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return p, false
+	}
+
+	p.segments = strings.Split(strings.ReplaceAll(line, "[!", "[^"), "/")
+
+	return p, true
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped with a
+// backslash
+func trimTrailingSpaces(line string) string {
+	trimmed := strings.TrimRight(line, " ")
+
+	if len(trimmed) < len(line) && strings.HasSuffix(trimmed, `\`) {
+		return trimmed + " "
+	}
+
+	return trimmed
+}
+
+// AddPatterns reads the patterns of a .gitignore file found in the base
+// directory. The base is slash separated and relative to the paths that will
+// be matched, an empty base stands for the root
+func (m *Matcher) AddPatterns(base string, r io.Reader) error {
+	base = strings.Trim(path.Clean("/"+base), "/")
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		p, ok := parsePattern(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		p.base = base
+		m.patterns = append(m.patterns, p)
+	}
+
+	return scanner.Err()
+}
+
+// AddFile reads the patterns of the .gitignore file in dir. The base is the
+// same directory relative to the root of the walk. A missing file is not an
+// error
+func (m *Matcher) AddFile(base, dir string) error {
+	file, err := os.Open(filepath.Join(dir, FILENAME))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return m.AddPatterns(base, file)
+}
+
+// Match reports whether the slash separated path, relative to the root, is
+// ignored. The last pattern that matches decides, negated patterns re-include
+// the path
+func (m *Matcher) Match(name string, isDir bool) bool {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	ignored := false
+
+	for _, p := range m.patterns {
+		if p.matches(name, isDir) {
+			ignored = !p.negate
+		}
+	}
+
+	return ignored
+}
+
+func (p pattern) matches(name string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	rel := name
+	if p.base != "" {
+		if !strings.HasPrefix(name, p.base+"/") {
+			return false
+		}
+		rel = name[len(p.base)+1:]
+	}
+
+	if !p.anchored {
+		ok, _ := path.Match(p.segments[0], path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches the glob segments against the path elements, where a
+// "**" segment matches any number of elements. A trailing "**" matches what is
+// inside of a directory but not the directory itself, so it needs at least one
+func matchSegments(segments, elements []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == "**" {
+			if len(segments) == 1 {
+				return len(elements) > 0
+			}
+
+			for i := 0; i <= len(elements); i++ {
+				if matchSegments(segments[1:], elements[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elements) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(segments[0], elements[0]); !ok {
+			return false
+		}
+
+		segments = segments[1:]
+		elements = elements[1:]
+	}
+
+	return len(elements) == 0
+}
@@ -0,0 +1,168 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/gitignore"
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestMatch(t *testing.T) {
+	type file struct {
+		base    string
+		content string
+	}
+
+	type check struct {
+		path  string
+		isDir bool
+		wants bool
+	}
+
+	testCases := []struct {
+		name   string
+		files  []file
+		checks []check
+	}{
+		{
+			name:  "name at any depth",
+			files: []file{{content: "*.log\nbuild\n"}},
+			checks: []check{
+				{path: "app.log", wants: true},
+				{path: "logs/app.log", wants: true},
+				{path: "app.go", wants: false},
+				{path: "build", isDir: true, wants: true},
+				{path: "src/build", isDir: true, wants: true},
+				{path: "build.go", wants: false},
+			},
+		},
+		{
+			name:  "comments and blank lines",
+			files: []file{{content: "# comment\n\n   \n\\#hash\n"}},
+			checks: []check{
+				{path: "# comment", wants: false},
+				{path: "#hash", wants: true},
+			},
+		},
+		{
+			name:  "trailing spaces",
+			files: []file{{content: "a.txt   \nb\\ \n"}},
+			checks: []check{
+				{path: "a.txt", wants: true},
+				{path: "b ", wants: true},
+				{path: "b", wants: false},
+			},
+		},
+		{
+			name:  "directory only",
+			files: []file{{content: "out/\n"}},
+			checks: []check{
+				{path: "out", isDir: true, wants: true},
+				{path: "out", isDir: false, wants: false},
+				{path: "src/out", isDir: true, wants: true},
+			},
+		},
+		{
+			name:  "anchored",
+			files: []file{{content: "/root.txt\ndocs/*.md\n"}},
+			checks: []check{
+				{path: "root.txt", wants: true},
+				{path: "sub/root.txt", wants: false},
+				{path: "docs/a.md", wants: true},
+				{path: "docs/sub/a.md", wants: false},
+				{path: "sub/docs/a.md", wants: false},
+			},
+		},
+		{
+			name:  "double asterisk",
+			files: []file{{content: "**/gen\na/**/b.txt\nlogs/**\n"}},
+			checks: []check{
+				{path: "gen", isDir: true, wants: true},
+				{path: "x/y/gen", isDir: true, wants: true},
+				{path: "a/b.txt", wants: true},
+				{path: "a/x/y/b.txt", wants: true},
+				{path: "b.txt", wants: false},
+				{path: "logs/today.log", wants: true},
+				{path: "logs", isDir: true, wants: false},
+			},
+		},
+		{
+			name:  "negation inside a directory matched by a trailing double asterisk",
+			files: []file{{content: "foo/**\n!foo/keep.txt\n"}},
+			checks: []check{
+				{path: "foo", isDir: true, wants: false},
+				{path: "foo/keep.txt", wants: false},
+				{path: "foo/drop.txt", wants: true},
+				{path: "foo/sub", isDir: true, wants: true},
+			},
+		},
+		{
+			name:  "negation",
+			files: []file{{content: "*.txt\n!keep.txt\n\\!bang\n"}},
+			checks: []check{
+				{path: "a.txt", wants: true},
+				{path: "keep.txt", wants: false},
+				{path: "sub/keep.txt", wants: false},
+				{path: "!bang", wants: true},
+			},
+		},
+		{
+			name:  "negated character class",
+			files: []file{{content: "file[!0-9]\n"}},
+			checks: []check{
+				{path: "filea", wants: true},
+				{path: "file1", wants: false},
+			},
+		},
+		{
+			name: "nested files",
+			files: []file{
+				{content: "*.tmp\n"},
+				{base: "sub", content: "!important.tmp\n/local\n"},
+			},
+			checks: []check{
+				{path: "a.tmp", wants: true},
+				{path: "sub/a.tmp", wants: true},
+				{path: "sub/important.tmp", wants: false},
+				{path: "important.tmp", wants: true},
+				{path: "sub/local", wants: true},
+				{path: "local", wants: false},
+				{path: "other/local", wants: false},
+			},
+		},
+		{
+			name: "nested file only applies below its directory",
+			files: []file{
+				{base: "a", content: "*.go\n"},
+			},
+			checks: []check{
+				{path: "a/main.go", wants: true},
+				{path: "b/main.go", wants: false},
+				{path: "main.go", wants: false},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := gitignore.NewMatcher()
+			for _, f := range tc.files {
+				err := m.AddPatterns(f.base, strings.NewReader(f.content))
+				assert.Equal(t, nil, err)
+			}
+
+			for _, c := range tc.checks {
+				assert.Equal(t, c.wants, m.Match(c.path, c.isDir), c.path)
+			}
+		})
+	}
+}
+
+func TestAddFileMissing(t *testing.T) {
+	m := gitignore.NewMatcher()
+
+	err := m.AddFile("", t.TempDir())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, m.Match("a.txt", false))
+}
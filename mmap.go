@@ -0,0 +1,89 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+)
+
+var errMmapUnsupported = errors.New("memory mapping is not supported for this file")
+
+// countMapped counts the file by mapping it in memory instead of reading it.
+// It returns false when the file could not be mapped and has to be streamed
+// instead
+func countMapped(ctx context.Context, file *os.File, size int64, opts FileOptions) (Counts, bool, error) {
+	data, unmap, err := mmapFile(file, size)
+	if err != nil {
+		return Counts{}, false, nil
+	}
+	defer unmap()
+
+	if opts.splits(size) {
+		counts, err := getCountsChunked(ctx, mappedReader{data: data}, size, opts.chunks(), MIN_CHUNK_SIZE, opts)
+		return counts, true, err
+	}
+
+	counts, err := getCountsMapped(ctx, data, opts)
+	return counts, true, err
+}
+
+// mappedReader reads a file mapped in memory. Chunks read it from goroutines
+// of their own, so every read turns the fault of the file being truncated
+// while mapped into an error itself
+type mappedReader struct {
+	data []byte
+}
+
+func (m mappedReader) ReadAt(p []byte, off int64) (n int, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, fmt.Errorf("reading mapped file: %v", r)
+		}
+	}()
+
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// getCountsMapped counts the bytes directly, in blocks so the context can be
+// checked between them. The file being truncated while mapped makes reading
+// past its new end fault, that fault is returned as an error. Every block is
+// reported to opts.Progress once counted
+func getCountsMapped(ctx context.Context, data []byte, opts FileOptions) (counts Counts, err error) {
+	s := newScanner(opts.What, opts.Words)
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			counts = s.finish()
+			err = fmt.Errorf("reading mapped file: %v", r)
+		}
+	}()
+
+	for start := 0; start < len(data); start += BLOCK_SIZE {
+		if err := ctx.Err(); err != nil {
+			return s.finish(), err
+		}
+
+		block := data[start:min(start+BLOCK_SIZE, len(data))]
+		s.write(block)
+
+		if opts.Progress != nil {
+			opts.Progress(len(block))
+		}
+	}
+
+	return s.finish(), nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package counter
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the first size bytes of the file in memory for reading. The
+// returned function unmaps it
+func mmapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, nil, errMmapUnsupported
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the data is read once from start to end
+	syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package counter
+
+import "os"
+
+// mmapFile is only implemented on Linux, everywhere else files are streamed
+func mmapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, errMmapUnsupported
+}
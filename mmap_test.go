@@ -0,0 +1,158 @@
+package counter
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestCountFileMmap(t *testing.T) {
+	dname := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "empty file", content: ""},
+		{name: "small file", content: "one two three\nfour 日本語\tfive\n"},
+		{name: "multiple blocks", content: strings.Repeat("one two\tthree 😀\n", BLOCK_SIZE/8)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(dname, strings.ReplaceAll(tc.name, " ", "-"))
+			if err := os.WriteFile(filename, []byte(tc.content), 0o644); err != nil {
+				t.Fatal("failed to create file:", err)
+			}
+
+			wants := GetCounts(strings.NewReader(tc.content))
+
+			got, err := CountFileWith(context.Background(), filename, FileOptions{Mmap: true, ParallelThreshold: -1})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got, "mapped")
+
+			got, err = CountFileWith(context.Background(), filename, FileOptions{Mmap: true, ParallelThreshold: 1, Chunks: 3})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got, "mapped in parallel")
+		})
+	}
+}
+
+func TestCountFileMmapFallback(t *testing.T) {
+	// files in /proc report a size of zero, they can only be read
+	filename := "/proc/self/status"
+	if _, err := os.Stat(filename); err != nil {
+		t.Skip("no /proc file system")
+	}
+
+	got, err := CountFileWith(context.Background(), filename, FileOptions{Mmap: true})
+	assert.Equal(t, nil, err)
+
+	if got.lines == 0 {
+		t.Error("expected the file to be read")
+	}
+}
+
+func TestGetCountsMappedCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := getCountsMapped(ctx, []byte("one two\n"), FileOptions{What: COUNT_ALL})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, Counts{}, got)
+}
+
+func TestCountMappedChunksTruncated(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	content := strings.Repeat("one two three\n", 4*MIN_CHUNK_SIZE/14)
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal("failed to open file:", err)
+	}
+	defer file.Close()
+
+	data, unmap, err := mmapFile(file, int64(len(content)))
+	if err != nil {
+		t.Skip("memory mapping is not supported:", err)
+	}
+	defer unmap()
+
+	// reading the pages past the new end faults in every chunk
+	if err := os.Truncate(filename, 0); err != nil {
+		t.Fatal("failed to truncate file:", err)
+	}
+
+	_, err = getCountsChunked(context.Background(), mappedReader{data: data}, int64(len(data)), 4, MIN_CHUNK_SIZE, FileOptions{What: COUNT_ALL})
+	if err == nil || !strings.HasPrefix(err.Error(), "reading mapped file:") {
+		t.Fatal("expected a fault reading the mapped file, got:", err)
+	}
+}
+
+// BENCH_FILE_SIZE is the size of the file the file benchmarks count
+const BENCH_FILE_SIZE = 256 * 1024 * 1024
+
+// createBenchFile writes BENCH_FILE_SIZE bytes of generated log lines
+func createBenchFile(b *testing.B) string {
+	b.Helper()
+
+	filename := filepath.Join(b.TempDir(), "bench.log")
+
+	file, err := os.Create(filename)
+	if err != nil {
+		b.Fatal("failed to create file:", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if _, err := w.ReadFrom(&repeatReader{size: BENCH_FILE_SIZE}); err != nil {
+		b.Fatal("failed to write file:", err)
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal("failed to write file:", err)
+	}
+
+	return filename
+}
+
+// BenchmarkCountFile compares counting a file by mapping it in memory with
+// reading it through the original rune by rune counter and the block engine
+func BenchmarkCountFile(b *testing.B) {
+	filename := createBenchFile(b)
+
+	b.Run("single pass", func(b *testing.B) {
+		b.SetBytes(BENCH_FILE_SIZE)
+
+		for b.Loop() {
+			file, err := os.Open(filename)
+			if err != nil {
+				b.Fatal(err)
+			}
+			getCountsSinglePass(file)
+			file.Close()
+		}
+	})
+
+	for _, mmap := range []bool{false, true} {
+		name := "blocks"
+		if mmap {
+			name = "mmap"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(BENCH_FILE_SIZE)
+
+			for b.Loop() {
+				CountFileWith(context.Background(), filename, FileOptions{Mmap: mmap, ParallelThreshold: -1})
+			}
+		})
+	}
+}
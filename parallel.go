@@ -0,0 +1,223 @@
+package counter
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DEFAULT_PARALLEL_THRESHOLD is the file size from which CountFileWith splits
+// files in chunks counted concurrently
+const DEFAULT_PARALLEL_THRESHOLD = 64 * 1024 * 1024
+
+// MIN_CHUNK_SIZE keeps chunks large enough for the merge to be negligible
+const MIN_CHUNK_SIZE = 1024 * 1024
+
+// FileOptions configures how CountFileWith counts a file. The zero value uses
+// the defaults
+type FileOptions struct {
+	// ParallelThreshold is the size from which regular files are split in
+	// chunks counted concurrently. Zero means DEFAULT_PARALLEL_THRESHOLD and a
+	// negative value disables parallel counting
+	ParallelThreshold int64
+	// Chunks is the number of chunks a file is split in, zero means one per CPU
+	Chunks int
+	// Mmap counts regular files by mapping them in memory instead of reading
+	// them. Files that can't be mapped are read as usual
+	Mmap bool
+	// What selects the counts to compute, zero counts everything. When only
+	// bytes are selected the size of regular files is used instead of reading
+	// them. Files reporting a size of zero are still read, as their size may
+	// not be known in advance
+	What CountOptions
+	// Words decides what a word is, nil counts words separated by white
+	// space. Files are only split in chunks with the white space rule and
+	// without COUNT_STATS
+	Words WordRule
+	// Decompress counts the contents of gzip, bzip2, zlib and compress (.Z)
+	// files instead of their compressed bytes, the format is found from their
+	// magic bytes. Other files are counted as they are. The size read from the
+	// file is kept as the compressed byte count
+	Decompress bool
+	// Progress is called with the number of bytes of the file counted since
+	// the previous call, which add up to the size of the file once it's done.
+	// Compressed files and archives report the bytes read from disk. Chunks
+	// are counted concurrently, so it has to be safe for concurrent use
+	Progress func(n int)
+}
+
+// withProgress returns a reader reporting what is read from r to
+// opts.Progress
+func (opts FileOptions) withProgress(r io.Reader) io.Reader {
+	if opts.Progress == nil {
+		return r
+	}
+
+	return progressReader{r: r, progress: opts.Progress}
+}
+
+// progressReader reports the size of every read to its function
+type progressReader struct {
+	r        io.Reader
+	progress func(n int)
+}
+
+func (pr progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.progress(n)
+	}
+
+	return n, err
+}
+
+func (opts FileOptions) parallelThreshold() int64 {
+	if opts.ParallelThreshold == 0 {
+		return DEFAULT_PARALLEL_THRESHOLD
+	}
+
+	return opts.ParallelThreshold
+}
+
+// splits reports whether a file of the given size is split in chunks
+func (opts FileOptions) splits(size int64) bool {
+	threshold := opts.parallelThreshold()
+	return threshold >= 0 && size >= threshold && isWhitespace(opts.Words) && opts.What&COUNT_STATS == 0
+}
+
+func (opts FileOptions) chunks() int {
+	if opts.Chunks <= 0 {
+		return runtime.NumCPU()
+	}
+
+	return opts.Chunks
+}
+
+// chunkResult holds what is needed to merge the counts of a chunk with the
+// ones of the chunks around it
+type chunkResult struct {
+	counts Counts
+	head   lineHead
+	// hasBreak is set when the chunk has a line break, tailCol is the column
+	// of the text after the last one
+	hasBreak bool
+	tailCol  uint
+	// startsInWord is set when the first rune is not a space, endsInWord when
+	// the last one isn't
+	startsInWord bool
+	endsInWord   bool
+	err          error
+}
+
+// GetCountsParallel counts the first size bytes of r by splitting them in
+// chunks counted concurrently. The results are the same as counting it
+// sequentially: a word or a line spanning several chunks is only counted
+// once and chunks never start in the middle of a UTF-8 sequence
+func GetCountsParallel(ctx context.Context, r io.ReaderAt, size int64, chunks int) (Counts, error) {
+	return getCountsChunked(ctx, r, size, chunks, MIN_CHUNK_SIZE, FileOptions{What: COUNT_ALL})
+}
+
+// getCountsChunked counts the chunks with opts.What, reporting the bytes of
+// every chunk to opts.Progress as they are read
+func getCountsChunked(ctx context.Context, r io.ReaderAt, size int64, chunks int, minChunkSize int64, opts FileOptions) (Counts, error) {
+	offsets := chunkOffsets(r, size, chunks, minChunkSize)
+	results := make([]chunkResult, len(offsets)-1)
+
+	wg := sync.WaitGroup{}
+
+	for i := range results {
+		wg.Go(func() {
+			results[i] = countChunk(ctx, r, offsets[i], offsets[i+1], opts)
+		})
+	}
+
+	wg.Wait()
+
+	counts, err := mergeChunks(results)
+
+	return counts.only(opts.What), err
+}
+
+// chunkOffsets splits size in chunks of about the same size and returns their
+// boundaries, including 0 and size. Boundaries are moved past UTF-8
+// continuation bytes so every chunk starts at the beginning of a rune
+func chunkOffsets(r io.ReaderAt, size int64, chunks int, minChunkSize int64) []int64 {
+	chunkSize := max(size/int64(max(chunks, 1)), minChunkSize, 1)
+	offsets := []int64{0}
+	buf := make([]byte, utf8.UTFMax-1)
+
+	for off := chunkSize; off < size; off += chunkSize {
+		n, _ := r.ReadAt(buf, off)
+
+		skip := 0
+		for skip < n && !utf8.RuneStart(buf[skip]) {
+			skip++
+		}
+
+		if start := off + int64(skip); start > offsets[len(offsets)-1] && start < size {
+			offsets = append(offsets, start)
+		}
+	}
+
+	return append(offsets, size)
+}
+
+func countChunk(ctx context.Context, r io.ReaderAt, start, end int64, opts FileOptions) chunkResult {
+	section := io.NewSectionReader(r, start, end-start)
+
+	first := [utf8.UTFMax]byte{}
+	firstLen, _ := section.ReadAt(first[:], 0)
+	firstRune, _ := utf8.DecodeRune(first[:firstLen])
+
+	s := newScanner(opts.What, WhitespaceWords)
+	err := s.readFrom(contextReader{ctx: ctx, r: opts.withProgress(section)})
+	counts := s.finish()
+
+	return chunkResult{
+		counts:       counts,
+		head:         s.head,
+		hasBreak:     s.sawBreak,
+		tailCol:      s.col,
+		startsInWord: firstLen > 0 && !unicode.IsSpace(firstRune),
+		endsInWord:   s.inWord,
+		err:          err,
+	}
+}
+
+// mergeChunks adds up the counts of consecutive chunks. A word cut by the
+// boundary is counted by both chunks and the width of a line cut by it is
+// only known once the column it continues from is
+func mergeChunks(results []chunkResult) (Counts, error) {
+	total := Counts{}
+	col := uint(0)
+	inWord := false
+
+	for _, res := range results {
+		counts := res.counts
+
+		if inWord && res.startsInWord {
+			counts.words--
+		}
+
+		headCol := res.head.column(col)
+		counts.maxLineLength = max(counts.maxLineLength, headCol)
+
+		if res.hasBreak {
+			col = res.tailCol
+		} else {
+			col = headCol
+		}
+
+		total = total.Add(counts)
+		inWord = res.endsInWord
+
+		if res.err != nil {
+			return total, res.err
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,176 @@
+package counter
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+var parallelInputs = append([]string{
+	"ab\tcd\tef\n\tx\ty z\n",
+	"a\tb\tc\td\te\tf\tg\th\ti",
+	"word\nacross\nlines\n",
+	"日本\t語😀\tx\r\nmore\fbreaks",
+	strings.Repeat("x", 30) + "\t" + strings.Repeat("y", 11) + "\t\tz",
+}, engineInputs...)
+
+func TestGetCountsChunked(t *testing.T) {
+	for _, input := range parallelInputs {
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+		for _, chunks := range append([]int{len(input), len(input) + 1}, 1, 2, 3, 4, 5, 7, 11, 16) {
+			got, err := getCountsChunked(context.Background(), strings.NewReader(input), int64(len(input)), chunks, 1, FileOptions{What: COUNT_ALL})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got, input)
+		}
+	}
+}
+
+func TestGetCountsChunkedWith(t *testing.T) {
+	for _, input := range parallelInputs {
+		all, _ := getCountsSinglePass(strings.NewReader(input))
+
+		for _, what := range []CountOptions{COUNT_LINES, COUNT_CHARS | COUNT_BYTES, COUNT_WORDS, COUNT_MAX_LINE_LENGTH} {
+			got, err := getCountsChunked(context.Background(), strings.NewReader(input), int64(len(input)), 3, 1, FileOptions{What: what})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, all.only(what), got, fmt.Sprintf("%05b", what), input)
+		}
+	}
+}
+
+func FuzzGetCountsChunked(f *testing.F) {
+	for _, input := range parallelInputs {
+		f.Add(input, 3)
+	}
+
+	f.Fuzz(func(t *testing.T, input string, chunks int) {
+		chunks = max(chunks%64, 1)
+
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+		got, err := getCountsChunked(context.Background(), strings.NewReader(input), int64(len(input)), chunks, 1, FileOptions{What: COUNT_ALL})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, wants, got)
+	})
+}
+
+func TestChunkOffsets(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		chunks int
+		wants  []int64
+	}{
+		{name: "empty", input: "", chunks: 4, wants: []int64{0, 0}},
+		{name: "single chunk", input: "abcdef", chunks: 1, wants: []int64{0, 6}},
+		{name: "even chunks", input: "abcdef", chunks: 3, wants: []int64{0, 2, 4, 6}},
+		{name: "skips continuation bytes", input: "a日b", chunks: 5, wants: []int64{0, 1, 4, 5}},
+		{name: "more chunks than bytes", input: "ab", chunks: 8, wants: []int64{0, 1, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := chunkOffsets(strings.NewReader(tc.input), int64(len(tc.input)), tc.chunks, 1)
+			assert.Equal(t, tc.wants, got)
+		})
+	}
+}
+
+func TestLineHeadColumn(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		col   uint
+	}{
+		{name: "no tabs", input: "abc", col: 5},
+		{name: "tab at start", input: "\tab", col: 3},
+		{name: "tab after text", input: "abc\tde", col: 6},
+		{name: "several tabs", input: "a\tbcdefghij\tk", col: 7},
+		{name: "aligned column", input: "ab\tc", col: 8},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newScanner(COUNT_ALL, nil)
+			s.write([]byte(tc.input))
+			s.finish()
+
+			prefix := strings.Repeat("x", int(tc.col))
+			wants := CountMaxLineLength(strings.NewReader(prefix + tc.input))
+
+			assert.Equal(t, wants, s.head.column(tc.col))
+		})
+	}
+}
+
+func TestCountFileWithParallel(t *testing.T) {
+	content := strings.Repeat("one two\tthree 日本語\n", 1000)
+
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	wants := GetCounts(strings.NewReader(content))
+
+	got, err := CountFileWith(context.Background(), filename, FileOptions{ParallelThreshold: 1, Chunks: 4})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, wants, got)
+
+	got, err = CountFileWith(context.Background(), filename, FileOptions{ParallelThreshold: -1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, wants, got)
+}
+
+func TestCountFileProgress(t *testing.T) {
+	content := strings.Repeat("one two three 日本語\n", 200000)
+
+	dname := t.TempDir()
+	filename := filepath.Join(dname, "words.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+	gzipped := writeCompressed(t, dname, "words.txt.gz", []byte(content), func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+
+	testCases := []struct {
+		name     string
+		filename string
+		opts     FileOptions
+	}{
+		{name: "streamed", filename: filename, opts: FileOptions{ParallelThreshold: -1}},
+		{name: "chunks", filename: filename, opts: FileOptions{ParallelThreshold: 1, Chunks: 4}},
+		{name: "mapped", filename: filename, opts: FileOptions{ParallelThreshold: -1, Mmap: true}},
+		{name: "mapped chunks", filename: filename, opts: FileOptions{ParallelThreshold: 1, Chunks: 4, Mmap: true}},
+		{name: "only bytes", filename: filename, opts: FileOptions{What: COUNT_BYTES}},
+		{name: "decompressed", filename: gzipped, opts: FileOptions{Decompress: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := os.Stat(tc.filename)
+			if err != nil {
+				t.Fatal("failed to stat file:", err)
+			}
+
+			reported := atomic.Int64{}
+			calls := atomic.Int64{}
+			tc.opts.Progress = func(n int) {
+				reported.Add(int64(n))
+				calls.Add(1)
+			}
+
+			_, err = CountFileWith(context.Background(), tc.filename, tc.opts)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, info.Size(), reported.Load())
+			assert.Equal(t, true, calls.Load() > 0)
+		})
+	}
+}
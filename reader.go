@@ -0,0 +1,88 @@
+package counter
+
+import (
+	"io"
+	"time"
+)
+
+type ReaderOptions struct {
+	// What selects the counts to compute, zero counts everything
+	What CountOptions
+	// Words decides what a word is, nil counts words separated by white
+	// space. With a rule other than the built-in ones the words of the line
+	// being read are only reported once its line break or io.EOF was read
+	Words WordRule
+	// Every calls OnProgress once at least this many bytes were read since the
+	// last call, zero disables it
+	Every uint
+	// Interval calls OnProgress once this much time went by since the last
+	// call. Time is only checked when reading, a reader blocked for longer
+	// reports late. Zero disables it
+	Interval time.Duration
+	// OnProgress receives the counts of everything read so far. It's called
+	// from Read, so it should return quickly
+	OnProgress func(Counts)
+}
+
+// Reader counts the data read through it and reports the progress while the
+// caller consumes it. OnProgress is called whenever Every or Interval is
+// reached, and once more with the final counts when the underlying reader
+// returns an error, io.EOF included
+type Reader struct {
+	r       io.Reader
+	opts    ReaderOptions
+	counter Counter
+	// unreported is the number of bytes read since the last call and last is
+	// the time of that call
+	unreported uint
+	last       time.Time
+	done       bool
+	now        func() time.Time
+}
+
+func NewReader(r io.Reader, opts ReaderOptions) *Reader {
+	return &Reader{
+		r:       r,
+		opts:    opts,
+		counter: Counter{what: opts.What, rule: opts.Words},
+		last:    time.Now(),
+		now:     time.Now,
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.counter.Write(p[:n])
+	r.unreported += uint(n)
+
+	switch {
+	case err != nil:
+		if !r.done {
+			r.done = true
+			if err == io.EOF {
+				r.counter.Finish()
+			}
+			r.report()
+		}
+	case r.opts.Every > 0 && r.unreported >= r.opts.Every:
+		r.report()
+	case r.opts.Interval > 0 && n > 0 && r.now().Sub(r.last) >= r.opts.Interval:
+		r.report()
+	}
+
+	return n, err
+}
+
+// Counts returns the counts of everything read so far
+func (r *Reader) Counts() Counts {
+	return r.counter.Counts()
+}
+
+func (r *Reader) report() {
+	r.unreported = 0
+	r.last = r.now()
+
+	if r.opts.OnProgress != nil {
+		r.opts.OnProgress(r.counter.Counts())
+	}
+}
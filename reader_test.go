@@ -0,0 +1,139 @@
+package counter
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestReader(t *testing.T) {
+	input := strings.Repeat("one two three\n", 100)
+	wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+	reported := []uint{}
+	r := NewReader(iotest.OneByteReader(strings.NewReader(input)), ReaderOptions{
+		Every: 500,
+		OnProgress: func(c Counts) {
+			reported = append(reported, c.bytes)
+		},
+	})
+
+	data, err := io.ReadAll(r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, input, string(data))
+	assert.Equal(t, wants, r.Counts())
+
+	// every 500 bytes and once at EOF
+	assert.Equal(t, []uint{500, 1000, 1400}, reported)
+}
+
+func TestReaderWithRule(t *testing.T) {
+	input := strings.Repeat("don't-stop 日本\n", 2)
+	wants, err := GetCountsWithRule(strings.NewReader(input), COUNT_ALL, UnicodeWords)
+	assert.Equal(t, nil, err)
+
+	reported := []Counts{}
+	r := NewReader(iotest.OneByteReader(strings.NewReader(input)), ReaderOptions{
+		Words: UnicodeWords,
+		Every: 5,
+		OnProgress: func(c Counts) {
+			reported = append(reported, c)
+		},
+	})
+
+	_, err = io.Copy(io.Discard, r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(8), r.Counts().words)
+	assert.Equal(t, wants, r.Counts())
+
+	// every report counts the words read so far with the rule
+	for _, c := range reported {
+		read, _ := GetCountsWithRule(strings.NewReader(input[:c.bytes]), COUNT_ALL, UnicodeWords)
+		assert.Equal(t, read, c, input[:c.bytes])
+	}
+}
+
+func TestReaderWithRuleAtEOF(t *testing.T) {
+	input := "one two\nthree four"
+	rule := opaqueRule{rule: UnicodeWords}
+
+	wants, err := GetCountsWithRule(strings.NewReader(input), COUNT_ALL, rule)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(4), wants.words)
+
+	reported := []uint{}
+	r := NewReader(iotest.OneByteReader(strings.NewReader(input)), ReaderOptions{
+		Words: rule,
+		Every: 9,
+		OnProgress: func(c Counts) {
+			reported = append(reported, c.words)
+		},
+	})
+
+	_, err = io.Copy(io.Discard, r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, wants, r.Counts())
+
+	// the last line is only counted once the reader is done
+	assert.Equal(t, []uint{2, 2, 4}, reported)
+}
+
+func TestReaderInterval(t *testing.T) {
+	now := time.Unix(0, 0)
+
+	reported := []uint{}
+	r := NewReader(iotest.OneByteReader(strings.NewReader("a\nb\nc\nd\n")), ReaderOptions{
+		What:     COUNT_LINES,
+		Interval: time.Second,
+		OnProgress: func(c Counts) {
+			reported = append(reported, c.lines)
+		},
+	})
+	r.last = now
+	r.now = func() time.Time {
+		// every read takes half a second
+		now = now.Add(time.Second / 2)
+		return now
+	}
+
+	_, err := io.Copy(io.Discard, r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{lines: 4}, r.Counts())
+	assert.Equal(t, []uint{1, 2, 3, 4, 4}, reported)
+}
+
+func TestReaderError(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+
+	calls := 0
+	var last Counts
+
+	r := NewReader(io.MultiReader(strings.NewReader("one two\n"), iotest.ErrReader(errBroken)), ReaderOptions{
+		OnProgress: func(c Counts) {
+			calls++
+			last = c
+		},
+	})
+
+	_, err := io.ReadAll(r)
+	assert.Equal(t, errBroken, err)
+
+	// reading again after the error doesn't report it twice
+	r.Read(make([]byte, 1))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, Counts{lines: 1, words: 2, chars: 8, bytes: 8, maxLineLength: 7}, last)
+}
+
+func TestReaderWithoutCallback(t *testing.T) {
+	r := NewReader(strings.NewReader("one two"), ReaderOptions{What: COUNT_WORDS, Every: 1})
+
+	_, err := io.Copy(io.Discard, r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{words: 2}, r.Counts())
+}
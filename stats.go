@@ -0,0 +1,268 @@
+package counter
+
+import (
+	"bytes"
+	"maps"
+	"math"
+	"slices"
+
+	"bloom.io/github.com/FerDev12/wc-go/display"
+)
+
+// Distribution summarizes a set of values without keeping every one of them,
+// only how many times each distinct value was seen. Line lengths take few
+// distinct values, so it stays small no matter how many lines there are
+type Distribution struct {
+	counts map[uint]uint
+	n      uint
+	sum    uint
+	min    uint
+	max    uint
+}
+
+func (d *Distribution) add(v uint) {
+	if d.counts == nil {
+		d.counts = map[uint]uint{}
+	}
+
+	if d.n == 0 || v < d.min {
+		d.min = v
+	}
+	d.max = max(d.max, v)
+
+	d.counts[v]++
+	d.n++
+	d.sum += v
+}
+
+// merge returns a distribution holding the values of both, neither of them
+// is modified
+func (d Distribution) merge(other Distribution) Distribution {
+	merged := Distribution{
+		counts: maps.Clone(d.counts),
+		n:      d.n + other.n,
+		sum:    d.sum + other.sum,
+		min:    d.min,
+		max:    max(d.max, other.max),
+	}
+
+	if d.n == 0 || (other.n > 0 && other.min < d.min) {
+		merged.min = other.min
+	}
+
+	if merged.counts == nil && other.n > 0 {
+		merged.counts = map[uint]uint{}
+	}
+	for v, n := range other.counts {
+		merged.counts[v] += n
+	}
+
+	return merged
+}
+
+func (d Distribution) clone() Distribution {
+	d.counts = maps.Clone(d.counts)
+	return d
+}
+
+func (d Distribution) equal(other Distribution) bool {
+	return d.n == other.n && d.sum == other.sum && d.min == other.min && d.max == other.max &&
+		maps.Equal(d.counts, other.counts)
+}
+
+// Count returns the number of values
+func (d Distribution) Count() uint {
+	return d.n
+}
+
+func (d Distribution) Min() uint {
+	return d.min
+}
+
+func (d Distribution) Max() uint {
+	return d.max
+}
+
+// Mean returns the average of the values, zero when there are none
+func (d Distribution) Mean() float64 {
+	if d.n == 0 {
+		return 0
+	}
+
+	return float64(d.sum) / float64(d.n)
+}
+
+// Percentile returns the smallest value that is at least as large as p percent
+// of the values, which is always one of the values. Zero when there are none
+func (d Distribution) Percentile(p float64) uint {
+	if d.n == 0 {
+		return 0
+	}
+
+	rank := uint(max(math.Ceil(p/100*float64(d.n)), 1))
+	seen := uint(0)
+
+	for _, v := range slices.Sorted(maps.Keys(d.counts)) {
+		seen += d.counts[v]
+		if seen >= rank {
+			return v
+		}
+	}
+
+	return d.max
+}
+
+func (d Distribution) Median() uint {
+	return d.Percentile(50)
+}
+
+// Summary returns the figures of the distribution that are displayed
+func (d Distribution) Summary() display.Summary {
+	return display.Summary{
+		Min:    d.Min(),
+		Max:    d.Max(),
+		Mean:   d.Mean(),
+		Median: d.Median(),
+		P95:    d.Percentile(95),
+		P99:    d.Percentile(99),
+	}
+}
+
+// Stats describes the lines of an input. Line lengths don't include the line
+// break, a last line without one is counted as long as it's not empty
+type Stats struct {
+	LineBytes Distribution
+	LineRunes Distribution
+	LineWords Distribution
+}
+
+// merge returns the stats of both inputs, either of which may be nil
+func (s *Stats) merge(other *Stats) *Stats {
+	switch {
+	case s == nil:
+		return other
+	case other == nil:
+		return s
+	}
+
+	return &Stats{
+		LineBytes: s.LineBytes.merge(other.LineBytes),
+		LineRunes: s.LineRunes.merge(other.LineRunes),
+		LineWords: s.LineWords.merge(other.LineWords),
+	}
+}
+
+func (s *Stats) equal(other *Stats) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+
+	return s.LineBytes.equal(other.LineBytes) &&
+		s.LineRunes.equal(other.LineRunes) &&
+		s.LineWords.equal(other.LineWords)
+}
+
+// Values returns the stats in a form the display package can serialize
+func (s *Stats) Values() *display.StatsValues {
+	if s == nil {
+		return nil
+	}
+
+	return &display.StatsValues{
+		LineBytes: s.LineBytes.Summary(),
+		LineRunes: s.LineRunes.Summary(),
+		LineWords: s.LineWords.Summary(),
+	}
+}
+
+// lineStats measures every line of a stream handed to it in blocks of any
+// size. Words are counted with a counter of their own, read after every line
+// break
+type lineStats struct {
+	stats Stats
+	words WordCounter
+	carry runeCarry
+
+	// bytes and runes of the current line so far, and the words counted
+	// before it started
+	bytes       uint
+	runes       uint
+	wordsBefore uint
+}
+
+func newLineStats(rule WordRule) *lineStats {
+	if rule == nil {
+		rule = WhitespaceWords
+	}
+
+	return &lineStats{words: rule.NewWordCounter()}
+}
+
+// clone returns a copy that can be finished without changing the original.
+// When the words counter can't be cloned the words of the current line are
+// left out of the copy
+func (ls *lineStats) clone() *lineStats {
+	c := *ls
+	c.stats = Stats{
+		LineBytes: ls.stats.LineBytes.clone(),
+		LineRunes: ls.stats.LineRunes.clone(),
+		LineWords: ls.stats.LineWords.clone(),
+	}
+
+	if words, ok := cloneWords(ls.words); ok {
+		c.words = words
+	} else {
+		c.words = fixedWords(ls.wordsBefore)
+	}
+
+	return &c
+}
+
+func (ls *lineStats) countRune(rune) {
+	ls.runes++
+}
+
+func (ls *lineStats) write(p []byte) {
+	for len(p) > 0 {
+		end := len(p)
+		line := p
+
+		i := bytes.IndexByte(p, '\n')
+		if i >= 0 {
+			end = i + 1
+			line = p[:i]
+		}
+
+		ls.bytes += uint(len(line))
+		ls.carry.each(line, ls.countRune)
+		ls.words.Write(p[:end])
+
+		if i >= 0 {
+			ls.endLine()
+		}
+
+		p = p[end:]
+	}
+}
+
+// endLine records the current line and starts a new one
+func (ls *lineStats) endLine() {
+	ls.carry.flush(ls.countRune)
+
+	words := ls.words.Words()
+
+	ls.stats.LineBytes.add(ls.bytes)
+	ls.stats.LineRunes.add(ls.runes)
+	ls.stats.LineWords.add(words - ls.wordsBefore)
+
+	ls.bytes, ls.runes, ls.wordsBefore = 0, 0, words
+}
+
+// finish returns the stats once the whole stream has been written
+func (ls *lineStats) finish() *Stats {
+	if ls.bytes > 0 || ls.carry.npending > 0 {
+		ls.endLine()
+	}
+
+	return &ls.stats
+}
@@ -0,0 +1,170 @@
+package counter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/display"
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func newDistribution(values ...uint) Distribution {
+	d := Distribution{}
+	for _, v := range values {
+		d.add(v)
+	}
+	return d
+}
+
+func TestDistribution(t *testing.T) {
+	testCases := []struct {
+		name   string
+		values []uint
+		wants  display.Summary
+	}{
+		{name: "empty", values: nil, wants: display.Summary{}},
+		{name: "single value", values: []uint{7}, wants: display.Summary{Min: 7, Max: 7, Mean: 7, Median: 7, P95: 7, P99: 7}},
+		{name: "even count", values: []uint{4, 1, 3, 2}, wants: display.Summary{Min: 1, Max: 4, Mean: 2.5, Median: 2, P95: 4, P99: 4}},
+		{name: "repeated values", values: []uint{0, 0, 0, 10}, wants: display.Summary{Min: 0, Max: 10, Mean: 2.5, Median: 0, P95: 10, P99: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newDistribution(tc.values...)
+			assert.Equal(t, uint(len(tc.values)), d.Count())
+			assert.Equal(t, tc.wants, d.Summary())
+		})
+	}
+
+	t.Run("percentiles of a hundred values", func(t *testing.T) {
+		d := Distribution{}
+		for v := uint(100); v > 0; v-- {
+			d.add(v)
+		}
+
+		assert.Equal(t, uint(50), d.Median())
+		assert.Equal(t, uint(95), d.Percentile(95))
+		assert.Equal(t, uint(99), d.Percentile(99))
+		assert.Equal(t, uint(1), d.Percentile(0))
+		assert.Equal(t, uint(100), d.Percentile(100))
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		a := newDistribution(5, 9)
+		b := newDistribution(1, 5)
+
+		merged := a.merge(b)
+		assert.Equal(t, newDistribution(1, 5, 5, 9), merged)
+
+		// neither side is modified
+		assert.Equal(t, newDistribution(5, 9), a)
+		assert.Equal(t, newDistribution(1, 5), b)
+
+		assert.Equal(t, a, a.merge(Distribution{}))
+		assert.Equal(t, b, Distribution{}.merge(b))
+	})
+}
+
+// statsSplit collects the stats writing the input in pieces of size n
+func statsSplit(rule WordRule, input string, n int) *Stats {
+	ls := newLineStats(rule)
+
+	for len(input) > 0 {
+		size := min(n, len(input))
+		ls.write([]byte(input[:size]))
+		input = input[size:]
+	}
+
+	return ls.finish()
+}
+
+func TestLineStats(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  WordRule
+		input string
+		bytes []uint
+		runes []uint
+		words []uint
+	}{
+		{name: "empty", input: ""},
+		{name: "blank lines", input: "\n\n", bytes: []uint{0, 0}, runes: []uint{0, 0}, words: []uint{0, 0}},
+		{name: "lines", input: "one two\nthree\n", bytes: []uint{7, 5}, runes: []uint{7, 5}, words: []uint{2, 1}},
+		{name: "unterminated last line", input: "one\ntwo three", bytes: []uint{3, 9}, runes: []uint{3, 9}, words: []uint{1, 2}},
+		{name: "multibyte runes", input: "héllo wörld\n日本語\n", bytes: []uint{13, 9}, runes: []uint{11, 3}, words: []uint{2, 1}},
+		{name: "invalid bytes", input: "a\xffb\n\xe2\x82", bytes: []uint{3, 2}, runes: []uint{3, 2}, words: []uint{1, 1}},
+		{name: "carriage return belongs to the line", input: "one\r\ntwo\n", bytes: []uint{4, 3}, runes: []uint{4, 3}, words: []uint{1, 1}},
+		{name: "unicode rule", rule: UnicodeWords, input: "日本語 e-mail\ndon't\n", bytes: []uint{16, 5}, runes: []uint{10, 5}, words: []uint{5, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wants := &Stats{
+				LineBytes: newDistribution(tc.bytes...),
+				LineRunes: newDistribution(tc.runes...),
+				LineWords: newDistribution(tc.words...),
+			}
+
+			for n := 1; n <= len(tc.input)+1; n++ {
+				assert.Equal(t, wants, statsSplit(tc.rule, tc.input, n), tc.input)
+			}
+		})
+	}
+}
+
+func TestGetCountsStats(t *testing.T) {
+	input := "one two\n\nthree four five\n"
+
+	got, err := GetCountsWith(strings.NewReader(input), COUNT_LINES|COUNT_STATS)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(3), got.lines)
+	assert.Equal(t, newDistribution(7, 0, 15), got.Stats().LineBytes)
+	assert.Equal(t, newDistribution(2, 0, 3), got.Stats().LineWords)
+
+	// the stats are only collected when asked for
+	got, err = GetCountsWith(strings.NewReader(input), COUNT_ALL)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*Stats)(nil), got.Stats())
+}
+
+func TestCountFileStats(t *testing.T) {
+	content := strings.Repeat("short\nmuch longer line\n", 1000)
+
+	filename := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	wants, err := GetCountsWith(strings.NewReader(content), COUNT_ALL|COUNT_STATS)
+	assert.Equal(t, nil, err)
+
+	testCases := []struct {
+		name string
+		opts FileOptions
+	}{
+		{name: "streamed", opts: FileOptions{What: COUNT_ALL | COUNT_STATS, ParallelThreshold: -1}},
+		{name: "not split in chunks", opts: FileOptions{What: COUNT_ALL | COUNT_STATS, ParallelThreshold: 1, Chunks: 4}},
+		{name: "mapped", opts: FileOptions{What: COUNT_ALL | COUNT_STATS, ParallelThreshold: 1, Chunks: 4, Mmap: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CountFileWith(context.Background(), filename, tc.opts)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got)
+		})
+	}
+
+	t.Run("total", func(t *testing.T) {
+		total := wants.Add(wants)
+
+		assert.Equal(t, uint(4000), total.Stats().LineBytes.Count())
+		assert.Equal(t, uint(5), total.Stats().LineBytes.Min())
+		assert.Equal(t, uint(16), total.Stats().LineBytes.Max())
+		// the counts added are left as they were
+		assert.Equal(t, uint(2000), wants.Stats().LineBytes.Count())
+	})
+}
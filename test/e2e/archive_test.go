@@ -0,0 +1,98 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestArchive(t *testing.T) {
+	dname := t.TempDir()
+
+	buffer := bytes.Buffer{}
+	zw := zip.NewWriter(&buffer)
+	for name, content := range map[string]string{"a.txt": "one two\n", "dir/b.txt": "three four five\nsix\n"} {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal("failed to write zip:", err)
+		}
+		fw.Write([]byte(content))
+	}
+	zw.Close()
+
+	archive := filepath.Join(dname, "archive.zip")
+	if err := os.WriteFile(archive, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	plain, err := createFile(dname, "seven eight\n")
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	cmd, err := getCommand("-archive", "-format", "csv", archive, plain.Name())
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	// zip.Writer keeps the order the files were created in, which is random
+	// when ranging over a map, so only the rows are compared
+	rows := map[string]bool{}
+	for _, line := range bytes.Split(bytes.TrimSpace(output), []byte("\n")) {
+		rows[string(line)] = true
+	}
+
+	wants := []string{
+		"lines,words,bytes,filename",
+		fmt.Sprintf("1,2,8,%s:a.txt", archive),
+		fmt.Sprintf("2,4,20,%s:dir/b.txt", archive),
+		fmt.Sprintf("3,6,28,%s", archive),
+		fmt.Sprintf("1,2,12,%s", plain.Name()),
+		"4,8,40,total",
+	}
+
+	assert.Equal(t, len(wants), len(rows), string(output))
+	for _, row := range wants {
+		if !rows[row] {
+			t.Errorf("expected row %q in:\n%s", row, output)
+		}
+	}
+}
+
+func TestArchiveNotFlagged(t *testing.T) {
+	buffer := bytes.Buffer{}
+	zw := zip.NewWriter(&buffer)
+	fw, _ := zw.Create("a.txt")
+	fw.Write([]byte("one two\n"))
+	zw.Close()
+
+	archive := filepath.Join(t.TempDir(), "archive.zip")
+	if err := os.WriteFile(archive, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	cmd, err := getCommand("-c", archive)
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	wants := fmt.Sprintf("%d %s\n", buffer.Len(), archive)
+	if !bytes.Contains(output, []byte(wants)) || bytes.Contains(output, []byte(":a.txt")) {
+		t.Errorf("expected the archive to be counted as a file, got:\n%s", output)
+	}
+}
@@ -144,6 +144,16 @@ func TestFlags(t *testing.T) {
 			},
 			wants: fmt.Sprintf(`    28 %s
     28 total
+`, file.Name()),
+		},
+		{
+			name: "-m (chars) flag",
+			input: inputs{
+				flags:   []string{"-m"},
+				content: "one two three\nfour five six\n",
+			},
+			wants: fmt.Sprintf(`    28 %s
+    28 total
 `, file.Name()),
 		},
 		{
@@ -216,3 +226,70 @@ func TestFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestCharsFlag(t *testing.T) {
+	cmd, err := getCommand("-m", "-c")
+	if err != nil {
+		t.Fatal("couldn't get working directory:", err)
+	}
+
+	cmd.Stdin = strings.NewReader("héllo wörld\n")
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	wants := "    12    14\n"
+	got := string(output)
+	assert.Equal(t, wants, got, "stdout is not correct")
+}
+
+func TestDirectory(t *testing.T) {
+	dname := t.TempDir()
+
+	cmd, err := getCommand(dname)
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	stdout := &bytes.Buffer{}
+
+	cmd.Stderr = stderr
+	cmd.Stdout = stdout
+
+	if err = cmd.Run(); err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	assert.Equal(t, fmt.Sprintf("wc-go: read %s: is a directory\n", dname), stderr.String(), "stderr is not correct")
+	assert.Equal(t, "    0    0    0 total\n", stdout.String(), "stdout is not correct")
+}
+
+func TestBytesOnlyStdinRedirect(t *testing.T) {
+	file, err := createFile(t.TempDir(), "one two three\nfour five six\n")
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	stdin, err := os.Open(file.Name())
+	if err != nil {
+		t.Fatal("failed to open file:", err)
+	}
+	defer stdin.Close()
+
+	cmd, err := getCommand("-c")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	cmd.Stdin = stdin
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	assert.Equal(t, "    28\n", string(output), "stdout is not correct")
+}
@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	dname := t.TempDir()
+
+	buffer := bytes.Buffer{}
+	w := gzip.NewWriter(&buffer)
+	w.Write([]byte(strings.Repeat("one two three\n", 100)))
+	w.Close()
+
+	gzipped := filepath.Join(dname, "words.txt.gz")
+	if err := os.WriteFile(gzipped, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	plain, err := createFile(dname, "four five\n")
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	for _, flag := range []string{"-z", "-decompress"} {
+		t.Run(flag, func(t *testing.T) {
+			cmd, err := getCommand(flag, "-l", "-c", gzipped, plain.Name())
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			size := buffer.Len()
+			// plain files have no compressed size
+			wants := fmt.Sprintf("    100    1400    %d %s\n      1      10     0 %s\n    101    1410    %d total\n", size, gzipped, plain.Name(), size)
+			assert.Equal(t, wants, string(output), "stdout is not correct")
+		})
+	}
+}
+
+func TestDecompressStdin(t *testing.T) {
+	buffer := bytes.Buffer{}
+	w := gzip.NewWriter(&buffer)
+	w.Write([]byte("one two\nthree\n"))
+	w.Close()
+
+	cmd, err := getCommand("-z", "-format", "json")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	cmd.Stdin = bytes.NewReader(buffer.Bytes())
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	wants := fmt.Sprintf(`"lines": 2,
+      "words": 3,
+      "chars": 14,
+      "bytes": 14,
+      "max_line_length": 7,
+      "compressed_bytes": %d`, buffer.Len())
+
+	if !strings.Contains(string(output), wants) {
+		t.Errorf("expected the decompressed counts, got:\n%s", output)
+	}
+}
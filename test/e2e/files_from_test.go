@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestFilesFrom(t *testing.T) {
+	dname := t.TempDir()
+
+	fileA, err := createFile(dname, "one two three\n")
+	if err != nil {
+		t.Fatal("failed to create fileA:", err)
+	}
+
+	fileB, err := createFile(dname, "four\nfive\n")
+	if err != nil {
+		t.Fatal("failed to create fileB:", err)
+	}
+
+	wants := fmt.Sprintf("    1 %s\n    2 %s\n    3 total\n", fileA.Name(), fileB.Name())
+
+	nulList := fileA.Name() + "\x00" + fileB.Name() + "\x00"
+	lineList := fileA.Name() + "\n" + fileB.Name()
+
+	listFile := filepath.Join(dname, "list")
+	if err := os.WriteFile(listFile, []byte(nulList), 0o644); err != nil {
+		t.Fatal("failed to create list:", err)
+	}
+
+	testCases := []struct {
+		name  string
+		flags []string
+		stdin string
+	}{
+		{name: "files0-from file", flags: []string{"--files0-from=" + listFile}},
+		{name: "files0-from stdin", flags: []string{"--files0-from=-"}, stdin: nulList},
+		{name: "files-from stdin", flags: []string{"-files-from", "-"}, stdin: lineList},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(append([]string{"-l"}, tc.flags...)...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			cmd.Stdin = strings.NewReader(tc.stdin)
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			assert.Equal(t, wants, string(output), "stdout is not correct")
+		})
+	}
+}
+
+func TestFilesFromEmptyName(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, "one two three\n")
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	cmd, err := getCommand("-l", "--files0-from=-")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.Stdin = strings.NewReader("\x00" + file.Name() + "\x00")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	assert.Equal(t, "wc-go: -:1: invalid zero-length file name\n", stderr.String(), "stderr is not correct")
+	assert.Equal(t, fmt.Sprintf("    1 %s\n    1 total\n", file.Name()), stdout.String(), "stdout is not correct")
+}
+
+func TestFilesFromWithOperands(t *testing.T) {
+	cmd, err := getCommand("--files0-from=-", "a.txt")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	cmd.Stdin = strings.NewReader("")
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	assert.Equal(t, "wc-go: extra operand \"a.txt\", file operands cannot be combined with -files0-from\n", string(output))
+}
@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestFollow(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, "one two\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := getCommand("-f", "-l", "-w", file.Name())
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal("failed to get stdout:", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	lines := bufio.NewScanner(stdout)
+	nextLine := func() string {
+		if !lines.Scan() {
+			t.Fatal("missing output:", lines.Err())
+		}
+		return strings.Join(strings.Fields(lines.Text()), " ")
+	}
+
+	assert.Equal(t, fmt.Sprintf("1 2 %s", file.Name()), nextLine(), "first count")
+
+	f, err := os.OpenFile(file.Name(), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal("failed to open file:", err)
+	}
+	f.WriteString("three four five\n")
+	f.Close()
+
+	assert.Equal(t, fmt.Sprintf("2 5 %s", file.Name()), nextLine(), "after appending")
+
+	cmd.Process.Signal(os.Interrupt)
+
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &exitErr) {
+		t.Fatal("expected the command to be interrupted, got:", err)
+	}
+	assert.Equal(t, 130, exitErr.ExitCode())
+}
+
+func TestFollowInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		flags []string
+		wants string
+	}{
+		{name: "no file", flags: []string{"-f"}, wants: "wc-go: -f takes a single file, got 0\n"},
+		{name: "several files", flags: []string{"-f", "a.log", "b.log"}, wants: "wc-go: -f takes a single file, got 2\n"},
+		{name: "json", flags: []string{"-f", "-format", "json", "a.log"}, wants: "wc-go: -f only supports the table format\n"},
+		{name: "word rule", flags: []string{"-f", "-word-rule", "unicode", "a.log"}, wants: "wc-go: -f cannot be used with -word-rule or -word-regex\n"},
+		{name: "decompress", flags: []string{"-f", "-z", "a.log.gz"}, wants: "wc-go: -f cannot be used with -z\n"},
+		{name: "stats", flags: []string{"-f", "-stats", "a.log"}, wants: "wc-go: -f cannot be used with -stats\n"},
+		{name: "archive", flags: []string{"-f", "-archive", "a.tar"}, wants: "wc-go: -f cannot be used with -archive\n"},
+		{name: "recursive", flags: []string{"-f", "-r", "logs"}, wants: "wc-go: -f cannot be used with -r\n"},
+		{name: "file list", flags: []string{"-f", "-files-from", "list.txt"}, wants: "wc-go: -f cannot be used with -files-from\n"},
+		{name: "nul file list", flags: []string{"-f", "-files0-from", "list.txt"}, wants: "wc-go: -f cannot be used with -files0-from\n"},
+		{name: "progress", flags: []string{"-f", "-progress", "a.log"}, wants: "wc-go: -f cannot be used with -progress\n"},
+		{name: "missing file", flags: []string{"-f", "missing.log"}, wants: "wc-go: open missing.log: no such file or directory\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(tc.flags...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			output, err := cmd.CombinedOutput()
+			if err == nil {
+				t.Fatal("expected the command to fail")
+			}
+
+			assert.Equal(t, tc.wants, string(output), "stderr is not correct")
+		})
+	}
+}
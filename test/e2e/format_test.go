@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/display"
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestJSONFormat(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, "one two three\nfour five six\n")
+	if err != nil {
+		t.Fatal("failed to create temp file:", err)
+	}
+
+	cmd, err := getCommand("-format", "json", file.Name(), "non-existent.txt")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+
+	if err = cmd.Run(); err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	got := display.Report{}
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal("failed to decode output:", err)
+	}
+
+	values := display.Values{Lines: 2, Words: 6, Chars: 28, Bytes: 28, MaxLineLength: 13}
+	wants := display.Report{
+		Files: []display.Record{
+			{Filename: file.Name(), Values: &values},
+			{Filename: "non-existent.txt", Error: "open non-existent.txt: no such file or directory"},
+		},
+		Total: values,
+	}
+
+	assert.Equal(t, wants, got)
+}
+
+func TestJSONFormatStdin(t *testing.T) {
+	cmd, err := getCommand("-format", "json")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	cmd.Stdin = strings.NewReader("one two three\n")
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	got := display.Report{}
+	if err := json.Unmarshal(output, &got); err != nil {
+		t.Fatal("failed to decode output:", err)
+	}
+
+	values := display.Values{Lines: 1, Words: 3, Chars: 14, Bytes: 14, MaxLineLength: 13}
+	wants := display.Report{
+		Files: []display.Record{{Values: &values}},
+		Total: values,
+	}
+
+	assert.Equal(t, wants, got)
+}
+
+func TestUnknownFormat(t *testing.T) {
+	cmd, err := getCommand("-format", "yaml")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	cmd.Stdin = strings.NewReader("")
+
+	if err = cmd.Run(); err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	assert.Equal(t, "wc-go: unknown format \"yaml\"\n", stderr.String(), "stderr is not correct")
+}
+
+func TestDelimitedFormats(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, "one two three\nfour five six\n")
+	if err != nil {
+		t.Fatal("failed to create temp file:", err)
+	}
+
+	testCases := []struct {
+		name  string
+		flags []string
+		wants string
+	}{
+		{
+			name:  "csv",
+			flags: []string{"-format", "csv"},
+			wants: "lines,words,bytes,filename\n2,6,28," + file.Name() + "\n2,6,28,total\n",
+		},
+		{
+			name:  "tsv with selected columns",
+			flags: []string{"-format", "tsv", "-l", "-m"},
+			wants: "lines\tcharacters\tfilename\n2\t28\t" + file.Name() + "\n2\t28\ttotal\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(append(tc.flags, file.Name())...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			assert.Equal(t, tc.wants, string(output), "stdout is not correct")
+		})
+	}
+}
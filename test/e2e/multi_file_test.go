@@ -50,3 +50,40 @@ func TestMultiFile(t *testing.T) {
 
 	assert.Equal(t, wants, got)
 }
+
+func TestMultiFileMaxLineLength(t *testing.T) {
+	dname, err := os.MkdirTemp("", "multi-file-test")
+	if err != nil {
+		t.Fatal("failed to create directory:", err)
+	}
+
+	defer os.RemoveAll(dname)
+
+	fileA, err := createFile(dname, "short\nthe longest line here\n")
+	if err != nil {
+		t.Fatal("failed to create fileA:", err)
+	}
+
+	fileB, err := createFile(dname, "a\tb\n")
+	if err != nil {
+		t.Fatal("failed to create fileB:", err)
+	}
+
+	cmd, err := getCommand("-l", "-L", fileA.Name(), fileB.Name())
+	if err != nil {
+		t.Fatal("failed to create command:", err)
+	}
+
+	stdout, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	got := string(stdout)
+	wants := fmt.Sprintf(`    2    21 %s
+    1     9 %s
+    3    21 total
+`, fileA.Name(), fileB.Name())
+
+	assert.Equal(t, wants, got)
+}
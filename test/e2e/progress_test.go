@@ -0,0 +1,37 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestProgressNotTerminal(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, "one two three\nfour\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := getCommand("-progress", "-l", file.Name(), "missing.txt")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// missing.txt makes it fail
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the command to fail")
+	}
+
+	// stderr is not a terminal, so only the error is written to it
+	wants := fmt.Sprintf("    2 %s\n    2 total\n", file.Name())
+	assert.Equal(t, wants, stdout.String(), "stdout is not correct")
+	assert.Equal(t, "wc-go: open missing.txt: no such file or directory\n", stderr.String(), "stderr is not correct")
+}
@@ -0,0 +1,159 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+// createTree creates the given files, relative to a new temporary directory,
+// and returns the directory
+func createTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dname := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dname, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal("failed to create directory:", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal("failed to create file:", err)
+		}
+	}
+
+	return dname
+}
+
+func TestRecursive(t *testing.T) {
+	dname := createTree(t, map[string]string{
+		"main.go":            "package main\n",
+		"README.md":          "one two\nthree\n",
+		"pkg/lib.go":         "package lib\n\nfunc A() {}\n",
+		"pkg/lib_test.go":    "package lib\n",
+		"vendor/dep/dep.go":  "package dep\n",
+		"vendor/dep/doc.txt": "docs\n",
+	})
+
+	testCases := []struct {
+		name  string
+		flags []string
+		wants []string
+	}{
+		{
+			name:  "every file",
+			flags: []string{"-r", "-l"},
+			wants: []string{"README.md", "main.go", "pkg/lib.go", "pkg/lib_test.go", "vendor/dep/dep.go", "vendor/dep/doc.txt"},
+		},
+		{
+			name:  "include",
+			flags: []string{"-r", "-l", "-include", "*.go"},
+			wants: []string{"main.go", "pkg/lib.go", "pkg/lib_test.go", "vendor/dep/dep.go"},
+		},
+		{
+			name:  "repeated include and exclude",
+			flags: []string{"-r", "-l", "-include", "*.go", "-include", "*.md", "-exclude", "*_test.go", "-exclude", "vendor"},
+			wants: []string{"README.md", "main.go", "pkg/lib.go"},
+		},
+	}
+
+	lines := map[string]int{
+		"README.md":          2,
+		"main.go":            1,
+		"pkg/lib.go":         3,
+		"pkg/lib_test.go":    1,
+		"vendor/dep/dep.go":  1,
+		"vendor/dep/doc.txt": 1,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(append(tc.flags, dname)...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			wants := ""
+			total := 0
+			for _, name := range tc.wants {
+				wants += fmt.Sprintf("    %d %s\n", lines[name], filepath.Join(dname, name))
+				total += lines[name]
+			}
+			wants += fmt.Sprintf("    %d total\n", total)
+
+			assert.Equal(t, wants, string(output), "stdout is not correct")
+		})
+	}
+}
+
+func TestRecursiveEmptyDirectory(t *testing.T) {
+	dname := t.TempDir()
+
+	cmd, err := getCommand("-r", "-l", dname)
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	assert.Equal(t, "    0 total\n", string(output), "stdout is not correct")
+}
+
+func TestRecursiveGitIgnore(t *testing.T) {
+	dname := createTree(t, map[string]string{
+		".gitignore":         "*.log\nbuild/\n/vendor\nfoo/**\n!foo/keep.txt\n",
+		"foo/keep.txt":       "kept\n",
+		"foo/drop.txt":       "ignored\n",
+		"main.go":            "package main\n",
+		"app.log":            "ignored\n",
+		"build/out.go":       "ignored\n",
+		"vendor/dep.go":      "ignored\n",
+		"pkg/.gitignore":     "!keep.log\ngen.go\n",
+		"pkg/keep.log":       "kept\n",
+		"pkg/drop.log":       "ignored\n",
+		"pkg/gen.go":         "ignored\n",
+		"pkg/lib.go":         "package lib\n\nfunc A() {}\n",
+		"pkg/vendor/v.go":    "not anchored to pkg\n",
+		".git/HEAD":          "ref: refs/heads/main\n",
+		"docs/build/site.md": "ignored\n",
+	})
+
+	cmd, err := getCommand("-r", "-gitignore", "-l", "-exclude", ".gitignore", dname)
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	wants := fmt.Sprintf(`    1 %s
+    1 %s
+    1 %s
+    3 %s
+    1 %s
+    7 total
+`,
+		filepath.Join(dname, "foo/keep.txt"),
+		filepath.Join(dname, "main.go"),
+		filepath.Join(dname, "pkg/keep.log"),
+		filepath.Join(dname, "pkg/lib.go"),
+		filepath.Join(dname, "pkg/vendor/v.go"),
+	)
+
+	assert.Equal(t, wants, string(output), "stdout is not correct")
+}
@@ -0,0 +1,56 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestStats(t *testing.T) {
+	input := "one two three\nfour\n\nlonger line here ok\n"
+
+	testCases := []struct {
+		name  string
+		flags []string
+		wants string
+	}{
+		{
+			name:  "table",
+			flags: []string{"-l", "-stats"},
+			wants: "4\n\n" +
+				"    min    max    mean    median    p95    p99\n" +
+				"      0     19    9.00         4     19     19 line bytes\n" +
+				"      0     19    9.00         4     19     19 line runes\n" +
+				"      0      4    2.00         1      4      4 line words\n",
+		},
+		{
+			name:  "csv",
+			flags: []string{"-w", "-stats", "-format", "csv"},
+			wants: "words," +
+				"line bytes min,line bytes max,line bytes mean,line bytes median,line bytes p95,line bytes p99," +
+				"line runes min,line runes max,line runes mean,line runes median,line runes p95,line runes p99," +
+				"line words min,line words max,line words mean,line words median,line words p95,line words p99,filename\n" +
+				"8,0,19,9.00,4,19,19,0,19,9.00,4,19,19,0,4,2.00,1,4,4,\n" +
+				"8,0,19,9.00,4,19,19,0,19,9.00,4,19,19,0,4,2.00,1,4,4,total\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(tc.flags...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			cmd.Stdin = strings.NewReader(input)
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			assert.Equal(t, tc.wants, strings.TrimLeft(string(output), " "), "stdout is not correct")
+		})
+	}
+}
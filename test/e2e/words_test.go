@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestWordRules(t *testing.T) {
+	input := "don't e-mail 日本語のテキスト\n"
+
+	testCases := []struct {
+		name  string
+		flags []string
+		wants string
+	}{
+		{name: "whitespace", flags: []string{"-w"}, wants: "3\n"},
+		{name: "unicode", flags: []string{"-w", "-word-rule", "unicode"}, wants: "8\n"},
+		{name: "regex", flags: []string{"-w", "-word-regex", `[\p{L}]+(?:[-'][\p{L}]+)*`}, wants: "3\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(tc.flags...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			cmd.Stdin = strings.NewReader(input)
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			assert.Equal(t, tc.wants, strings.TrimLeft(string(output), " "), "stdout is not correct")
+		})
+	}
+}
+
+func TestWordRulesInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		flags []string
+		wants string
+	}{
+		{name: "unknown rule", flags: []string{"-word-rule", "sentences"}, wants: "wc-go: unknown word rule \"sentences\", must be one of: unicode, whitespace\n"},
+		{name: "invalid regex", flags: []string{"-word-regex", "("}, wants: "wc-go: invalid -word-regex: error parsing regexp: missing closing ): `(`\n"},
+		{name: "both flags", flags: []string{"-word-rule", "unicode", "-word-regex", `\w+`}, wants: "wc-go: -word-rule and -word-regex cannot be used together\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := getCommand(tc.flags...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			cmd.Stdin = strings.NewReader("one two\n")
+
+			output, err := cmd.CombinedOutput()
+			if err == nil {
+				t.Fatal("expected the command to fail")
+			}
+
+			assert.Equal(t, tc.wants, string(output))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package e2e
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestWorkersKeepOrder(t *testing.T) {
+	dname := t.TempDir()
+
+	filenames := []string{}
+	wants := ""
+
+	for i := range 50 {
+		content := strings.Repeat("line\n", i)
+		file, err := createFile(dname, content)
+		if err != nil {
+			t.Fatal("failed to create file:", err)
+		}
+
+		filenames = append(filenames, file.Name())
+		wants += fmt.Sprintf("%d %s\n", i, file.Name())
+	}
+	wants += "1225 total\n"
+
+	for _, workers := range []string{"1", "3", "64"} {
+		t.Run("-j "+workers, func(t *testing.T) {
+			cmd, err := getCommand(append([]string{"-l", "-format", "tsv", "-j", workers}, filenames...)...)
+			if err != nil {
+				t.Fatal("failed to get command:", err)
+			}
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatal("failed to run command:", err)
+			}
+
+			got := strings.SplitN(string(output), "\n", 2)[1]
+			assert.Equal(t, strings.ReplaceAll(wants, " ", "\t"), got, "stdout is not correct")
+		})
+	}
+}
+
+func TestWorkersOpenFileLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ulimit is not available")
+	}
+
+	dname := t.TempDir()
+
+	for range 500 {
+		if _, err := createFile(dname, "one two\n"); err != nil {
+			t.Fatal("failed to create file:", err)
+		}
+	}
+
+	bin, err := filepath.Abs(binName)
+	if err != nil {
+		t.Fatal("failed to get binary path:", err)
+	}
+
+	// with one goroutine per file this would fail with "too many open files"
+	cmd := exec.Command("sh", "-c", `ulimit -n 32 && exec "$0" -l -j 4 "$1"/*`, bin, dname)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal("failed to run command:", err, string(output))
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Equal(t, 501, len(lines), "unexpected number of rows")
+	assert.Equal(t, "500 total", strings.TrimSpace(lines[len(lines)-1]))
+}
+
+func TestInvalidWorkers(t *testing.T) {
+	cmd, err := getCommand("-j", "0")
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Error("command succeeded when it shouldn't")
+	}
+
+	assert.Equal(t, "wc-go: -j must be at least 1, got 0\n", string(output))
+}
+
+func TestParallelChunks(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, strings.Repeat("one two\tthree 日本語 four\n", 500))
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	run := func(flags ...string) string {
+		cmd, err := getCommand(append(append([]string{"-l", "-w", "-m", "-c", "-L"}, flags...), file.Name())...)
+		if err != nil {
+			t.Fatal("failed to get command:", err)
+		}
+
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatal("failed to run command:", err)
+		}
+
+		return string(output)
+	}
+
+	sequential := run("-parallel-threshold", "-1")
+	parallel := run("-parallel-threshold", "1", "-chunks", "7")
+
+	assert.Equal(t, sequential, parallel, "chunked counts differ from sequential")
+
+	// zero splits every file too
+	assert.Equal(t, sequential, run("-parallel-threshold", "0", "-chunks", "7"), "zero threshold differs from sequential")
+}
+
+func TestMmap(t *testing.T) {
+	dname := t.TempDir()
+
+	file, err := createFile(dname, strings.Repeat("one two\tthree 日本語 four\n", 50))
+	if err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	cmd, err := getCommand("-mmap", file.Name())
+	if err != nil {
+		t.Fatal("failed to get command:", err)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("failed to run command:", err)
+	}
+
+	wants := fmt.Sprintf("    50    250    1450 %s\n    50    250    1450 total\n", file.Name())
+	assert.Equal(t, wants, string(output), "stdout is not correct")
+}
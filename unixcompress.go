@@ -0,0 +1,228 @@
+package counter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Limits of the width of the codes in a .Z file
+const (
+	UNIX_COMPRESS_MIN_BITS = 9
+	UNIX_COMPRESS_MAX_BITS = 16
+)
+
+var errUnixCompressCorrupt = errors.New("compress: corrupt input")
+
+// unixCompressReader decodes the .Z format written by compress(1). The
+// compress/lzw package can't read it: .Z files start with a header, have no
+// end code, use codes of up to 16 bits, reset the table with a clear code and
+// pad the codes to groups of 8 every time their width changes
+type unixCompressReader struct {
+	r io.ByteReader
+
+	maxBits   uint
+	blockMode bool
+
+	bits  uint32
+	nbits uint
+	// width is the size of the codes being read and read is the number of
+	// codes read since it last changed, needed to skip the padding
+	width   uint
+	maxCode int
+	read    uint
+
+	prefix []uint16
+	suffix []byte
+	// next is the code of the next entry added to the table, prev the last
+	// code read and last the first byte of the string it decoded to
+	next int
+	prev int
+	last byte
+
+	out   []byte
+	stack []byte
+	err   error
+}
+
+// newUnixCompressReader reads the header of a .Z stream and returns a reader
+// for the decompressed data
+func newUnixCompressReader(r io.ByteReader) (io.Reader, error) {
+	header := [3]byte{}
+
+	for i := range header {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		header[i] = b
+	}
+
+	if header[0] != 0x1f || header[1] != 0x9d {
+		return nil, errors.New("compress: invalid header")
+	}
+
+	maxBits := uint(header[2] & 0x1f)
+	if maxBits < UNIX_COMPRESS_MIN_BITS || maxBits > UNIX_COMPRESS_MAX_BITS {
+		return nil, fmt.Errorf("compress: unsupported code width of %d bits", maxBits)
+	}
+
+	z := &unixCompressReader{
+		r:         r,
+		maxBits:   maxBits,
+		blockMode: header[2]&0x80 != 0,
+		prefix:    make([]uint16, 1<<maxBits),
+		suffix:    make([]byte, 1<<maxBits),
+		prev:      -1,
+	}
+
+	for i := range 256 {
+		z.suffix[i] = byte(i)
+	}
+
+	z.reset()
+
+	return z, nil
+}
+
+// reset goes back to the initial code width and table size
+func (z *unixCompressReader) reset() {
+	z.width = UNIX_COMPRESS_MIN_BITS
+	z.maxCode = 1<<z.width - 1
+
+	z.next = 256
+	if z.blockMode {
+		// 256 is the clear code
+		z.next = 257
+	}
+}
+
+func (z *unixCompressReader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 && z.err == nil {
+		z.err = z.decode()
+	}
+
+	if len(z.out) == 0 {
+		return 0, z.err
+	}
+
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+
+	return n, nil
+}
+
+// readCode reads the next code, least significant bits first. Running out of
+// input before a full code is the end of the stream
+func (z *unixCompressReader) readCode() (int, error) {
+	for z.nbits < z.width {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		z.bits |= uint32(b) << z.nbits
+		z.nbits += 8
+	}
+
+	code := int(z.bits & (1<<z.width - 1))
+	z.bits >>= z.width
+	z.nbits -= z.width
+	z.read++
+
+	return code, nil
+}
+
+// skipPadding discards the codes up to the end of the current group of 8
+func (z *unixCompressReader) skipPadding() error {
+	for z.read%8 != 0 {
+		if _, err := z.readCode(); err != nil {
+			return err
+		}
+	}
+
+	z.read = 0
+
+	return nil
+}
+
+// decode reads a single code and sets out to the bytes it stands for
+func (z *unixCompressReader) decode() error {
+	if z.next > z.maxCode {
+		if err := z.skipPadding(); err != nil {
+			return err
+		}
+
+		z.width++
+		z.maxCode = 1<<z.width - 1
+		if z.width == z.maxBits {
+			z.maxCode = 1 << z.maxBits
+		}
+	}
+
+	code, err := z.readCode()
+	if err != nil {
+		return err
+	}
+
+	if z.prev == -1 {
+		if code >= 256 {
+			return errUnixCompressCorrupt
+		}
+
+		z.prev = code
+		z.last = byte(code)
+		z.out = append(z.stack[:0], z.last)
+		return nil
+	}
+
+	if code == 256 && z.blockMode {
+		if err := z.skipPadding(); err != nil {
+			return err
+		}
+
+		z.reset()
+		// the code read after a clear adds an entry nothing refers to
+		z.next = 256
+		return nil
+	}
+
+	stack := z.stack[:0]
+	current := code
+
+	if code >= z.next {
+		// the code being defined, which is the previous string followed by its
+		// own first byte
+		if code > z.next {
+			return errUnixCompressCorrupt
+		}
+
+		stack = append(stack, z.last)
+		code = z.prev
+	}
+
+	for code >= 256 {
+		stack = append(stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+
+	z.last = byte(code)
+	stack = append(stack, z.last)
+	slices.Reverse(stack)
+
+	if z.next < 1<<z.maxBits {
+		z.prefix[z.next] = uint16(z.prev)
+		z.suffix[z.next] = z.last
+		z.next++
+	}
+
+	z.prev = current
+	z.stack = stack
+	z.out = stack
+
+	return nil
+}
@@ -0,0 +1,443 @@
+package counter
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordRule decides what a word is. Every counting function takes one, nil
+// stands for WhitespaceWords
+type WordRule interface {
+	// NewWordCounter returns a counter for a single input
+	NewWordCounter() WordCounter
+}
+
+// WordCounter counts the words of an input written to it in order, in blocks
+// of any size. Blocks may end in the middle of a UTF-8 sequence
+type WordCounter interface {
+	Write(p []byte) (int, error)
+	// Words returns the number of words once the whole input was written. It
+	// may also be called right after a line break, writing goes on afterwards
+	Words() uint
+}
+
+// cloneWords returns a copy of the counter that can be finished without
+// changing it, which only the counters of the built-in rules support
+func cloneWords(wc WordCounter) (WordCounter, bool) {
+	switch wc := wc.(type) {
+	case *whitespaceCounter:
+		return &whitespaceCounter{s: wc.s.clone()}, true
+	case *unicodeCounter:
+		c := *wc
+		return &c, true
+	case *regexCounter:
+		c := *wc
+		c.line = bytes.Clone(wc.line)
+		return &c, true
+	}
+
+	return nil, false
+}
+
+// canCloneWords reports whether cloneWords supports the counter
+func canCloneWords(wc WordCounter) bool {
+	switch wc.(type) {
+	case *whitespaceCounter, *unicodeCounter, *regexCounter:
+		return true
+	}
+
+	return false
+}
+
+// fixedWords stands for a counter that can't be cloned in a snapshot, it
+// returns the words it was created with whatever is written to it
+type fixedWords uint
+
+func (f fixedWords) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (f fixedWords) Words() uint {
+	return uint(f)
+}
+
+var (
+	// WhitespaceWords counts runs of characters separated by Unicode white
+	// space, the same as wc. It's the default and the fastest rule
+	WhitespaceWords WordRule = whitespaceRule{}
+	// UnicodeWords counts words following the word boundaries of Unicode
+	// UAX #29: letters and digits joined by apostrophes and the like, such as
+	// "don't" or "3.14", are a single word while hyphens split words. Every
+	// ideograph is a word of its own and punctuation or symbols are not words
+	UnicodeWords WordRule = unicodeRule{}
+)
+
+// isWhitespace reports whether the rule is the one the scanner implements
+// itself, which is also the only one the chunked counting can merge
+func isWhitespace(rule WordRule) bool {
+	_, ok := rule.(whitespaceRule)
+	return rule == nil || ok
+}
+
+type whitespaceRule struct{}
+
+func (whitespaceRule) NewWordCounter() WordCounter {
+	return &whitespaceCounter{s: newScanner(COUNT_WORDS, nil)}
+}
+
+// whitespaceCounter lets the scanner be used as any other word counter
+type whitespaceCounter struct {
+	s *scanner
+}
+
+func (w *whitespaceCounter) Write(p []byte) (int, error) {
+	w.s.write(p)
+	return len(p), nil
+}
+
+func (w *whitespaceCounter) Words() uint {
+	return w.s.finish().words
+}
+
+// runeCarry decodes blocks of bytes rune by rune, keeping the start of a UTF-8
+// sequence cut at the end of a block for the next one
+type runeCarry struct {
+	pending  [utf8.UTFMax]byte
+	npending int
+}
+
+// each calls fn with every complete rune in the block, invalid bytes are
+// handed out as one utf8.RuneError each
+func (c *runeCarry) each(p []byte, fn func(r rune)) {
+	if c.npending > 0 {
+		n := copy(c.pending[c.npending:], p)
+		seq := c.pending[:c.npending+n]
+
+		if !utf8.FullRune(seq) {
+			c.npending = len(seq)
+			return
+		}
+
+		r, size := utf8.DecodeRune(seq)
+		fn(r)
+
+		used := size - c.npending
+		if used < 0 {
+			// invalid sequence, the bytes after its first one come before p
+			rest := bytes.Clone(c.pending[size:c.npending])
+			c.npending = 0
+
+			c.each(rest, fn)
+			c.each(p, fn)
+			return
+		}
+
+		c.npending = 0
+		p = p[used:]
+	}
+
+	for len(p) > 0 {
+		if !utf8.FullRune(p) {
+			c.npending = copy(c.pending[:], p)
+			return
+		}
+
+		r, size := utf8.DecodeRune(p)
+		fn(r)
+		p = p[size:]
+	}
+}
+
+// flush hands out the bytes of an incomplete sequence left at the end
+func (c *runeCarry) flush(fn func(r rune)) {
+	for range c.npending {
+		fn(utf8.RuneError)
+	}
+	c.npending = 0
+}
+
+// Word break classes of UAX #29 that decide whether two characters belong to
+// the same word
+const (
+	wbOther = iota
+	wbLetter
+	wbNumeric
+	wbKatakana
+	wbExtendNumLet
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet
+	// wbIdeograph characters are words of their own
+	wbIdeograph
+	// wbExtend characters such as combining marks belong to the one before
+	wbExtend
+)
+
+// wordBreakClass approximates the Word_Break property of UAX #29 with the
+// Unicode categories and scripts in the standard library
+func wordBreakClass(r rune) int {
+	switch r {
+	case ':', '·', 0x0387, 0x055f, 0x05f4, 0x2027, 0xfe13, 0xfe55, 0xff1a:
+		return wbMidLetter
+	case ',', ';', 0x037e, 0x0589, 0x060c, 0x060d, 0x066c, 0x07f8, 0x2044, 0xfe10, 0xfe14, 0xfe50, 0xfe54, 0xff0c, 0xff1b:
+		return wbMidNum
+	case '.', '\'', 0x2018, 0x2019, 0x2024, 0xfe52, 0xff07, 0xff0e:
+		return wbMidNumLet
+	case 0x30fc:
+		// the prolonged sound mark is shared by hiragana and katakana
+		return wbKatakana
+	}
+
+	switch {
+	case r < utf8.RuneSelf:
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+			return wbLetter
+		case '0' <= r && r <= '9':
+			return wbNumeric
+		case r == '_':
+			return wbExtendNumLet
+		}
+		return wbOther
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf):
+		return wbExtend
+	case unicode.Is(unicode.Katakana, r):
+		return wbKatakana
+	case unicode.In(r, unicode.Han, unicode.Hiragana):
+		return wbIdeograph
+	case unicode.IsLetter(r):
+		return wbLetter
+	case unicode.IsDigit(r):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, r):
+		return wbExtendNumLet
+	}
+
+	return wbOther
+}
+
+type unicodeRule struct{}
+
+func (unicodeRule) NewWordCounter() WordCounter {
+	return &unicodeCounter{}
+}
+
+// unicodeCounter finds the word boundaries one rune at a time. Only the
+// segments holding a letter, a digit or an ideograph are counted as words
+type unicodeCounter struct {
+	carry runeCarry
+	words uint
+	// last is the class of the last character of the current segment, other
+	// when not in a segment. mid is the punctuation seen right after it, which
+	// only joins the segment with what follows in some cases
+	last    int
+	mid     int
+	counted bool
+}
+
+func (u *unicodeCounter) Write(p []byte) (int, error) {
+	u.carry.each(p, u.next)
+	return len(p), nil
+}
+
+func (u *unicodeCounter) Words() uint {
+	u.carry.flush(u.next)
+	return u.words
+}
+
+// joins reports whether a letter, digit or katakana of class c continues the
+// current segment
+func (u *unicodeCounter) joins(c int) bool {
+	switch u.mid {
+	case wbMidLetter:
+		return u.last == wbLetter && c == wbLetter
+	case wbMidNum:
+		return u.last == wbNumeric && c == wbNumeric
+	case wbMidNumLet:
+		return u.last == c && c != wbKatakana
+	}
+
+	switch u.last {
+	case wbLetter, wbNumeric:
+		return c == wbLetter || c == wbNumeric
+	case wbKatakana:
+		return c == wbKatakana
+	case wbExtendNumLet:
+		return true
+	}
+
+	return false
+}
+
+func (u *unicodeCounter) next(r rune) {
+	c := wordBreakClass(r)
+
+	switch c {
+	case wbExtend:
+	case wbLetter, wbNumeric, wbKatakana:
+		if !u.joins(c) {
+			u.counted = false
+		}
+		if !u.counted {
+			u.words++
+			u.counted = true
+		}
+		u.last, u.mid = c, wbOther
+	case wbExtendNumLet:
+		if u.last == wbOther || u.mid != wbOther {
+			u.counted = false
+		}
+		u.last, u.mid = c, wbOther
+	case wbMidLetter, wbMidNum, wbMidNumLet:
+		if u.mid == wbOther && (u.last == wbLetter || u.last == wbNumeric) {
+			u.mid = c
+		} else {
+			u.last, u.mid = wbOther, wbOther
+		}
+	case wbIdeograph:
+		u.words++
+		u.last, u.mid = wbOther, wbOther
+	default:
+		u.last, u.mid = wbOther, wbOther
+	}
+}
+
+// REGEX_MAX_LINE is the longest line RegexWords matches at once. Longer lines
+// are matched in pieces of this size, a word cut between two pieces is
+// counted in both
+const REGEX_MAX_LINE = 1024 * 1024
+
+// RegexWords counts every non empty match of the expression as a word. The
+// input is matched one line at a time, so words can't span line breaks, and
+// lines longer than REGEX_MAX_LINE are matched in pieces of that size
+func RegexWords(re *regexp.Regexp) WordRule {
+	return regexRule{re: re, anchored: hasContextAssertions(re)}
+}
+
+// hasContextAssertions reports whether matching the expression depends on the
+// text before where the search starts, such as ^ or \b do. Those have to be
+// matched over the whole line at once
+func hasContextAssertions(re *regexp.Regexp) bool {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return true
+	}
+
+	return hasOp(parsed, syntax.OpBeginLine, syntax.OpBeginText, syntax.OpWordBoundary, syntax.OpNoWordBoundary)
+}
+
+func hasOp(re *syntax.Regexp, ops ...syntax.Op) bool {
+	if slices.Contains(ops, re.Op) {
+		return true
+	}
+
+	for _, sub := range re.Sub {
+		if hasOp(sub, ops...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type regexRule struct {
+	re       *regexp.Regexp
+	anchored bool
+}
+
+func (rule regexRule) NewWordCounter() WordCounter {
+	return &regexCounter{re: rule.re, anchored: rule.anchored, maxLine: REGEX_MAX_LINE}
+}
+
+// regexCounter keeps the start of a line until its end is written, or until
+// it reaches maxLine
+type regexCounter struct {
+	re       *regexp.Regexp
+	anchored bool
+	maxLine  int
+	line     []byte
+	words    uint
+}
+
+func (rc *regexCounter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			rc.add(p)
+			return n, nil
+		}
+
+		if len(rc.line) == 0 && i < rc.maxLine {
+			rc.words += rc.count(p[:i])
+		} else {
+			rc.add(p[:i])
+			rc.words += rc.count(rc.line)
+			rc.line = rc.line[:0]
+		}
+
+		p = p[i+1:]
+	}
+}
+
+// add appends p to the current line, matching the line every time it reaches
+// maxLine
+func (rc *regexCounter) add(p []byte) {
+	for len(rc.line)+len(p) >= rc.maxLine {
+		take := rc.maxLine - len(rc.line)
+		rc.line = append(rc.line, p[:take]...)
+		rc.words += rc.count(rc.line)
+		rc.line = rc.line[:0]
+		p = p[take:]
+	}
+
+	rc.line = append(rc.line, p...)
+}
+
+func (rc *regexCounter) Words() uint {
+	rc.words += rc.count(rc.line)
+	rc.line = rc.line[:0]
+
+	return rc.words
+}
+
+// count returns the number of non empty matches in the line. Matches are found
+// one after the other without keeping them, unless the expression depends on
+// what comes before the search
+func (rc *regexCounter) count(line []byte) uint {
+	words := uint(0)
+
+	if rc.anchored {
+		for _, match := range rc.re.FindAllIndex(line, -1) {
+			if match[1] > match[0] {
+				words++
+			}
+		}
+
+		return words
+	}
+
+	for len(line) > 0 {
+		match := rc.re.FindIndex(line)
+		if match == nil {
+			break
+		}
+
+		if match[1] > match[0] {
+			words++
+			line = line[match[1]:]
+			continue
+		}
+
+		// skip a rune past an empty match
+		_, size := utf8.DecodeRune(line[match[1]:])
+		line = line[min(match[1]+size, len(line)):]
+	}
+
+	return words
+}
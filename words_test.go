@@ -0,0 +1,191 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+// countSplit counts the words writing the input to a new counter of the rule
+// in pieces of size n
+func countSplit(rule WordRule, input string, n int) uint {
+	wc := rule.NewWordCounter()
+
+	for len(input) > 0 {
+		size := min(n, len(input))
+		wc.Write([]byte(input[:size]))
+		input = input[size:]
+	}
+
+	return wc.Words()
+}
+
+func TestUnicodeWords(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		wants uint
+	}{
+		{name: "empty", input: "", wants: 0},
+		{name: "plain words", input: "one two  three\n", wants: 3},
+		{name: "apostrophe", input: "don't won’t", wants: 2},
+		{name: "hyphen splits", input: "e-mail", wants: 2},
+		{name: "punctuation is not a word", input: "hello, world! -- ... ?", wants: 2},
+		{name: "numbers", input: "3.14 1,000 1.5.2", wants: 3},
+		{name: "letters and digits", input: "abc123 4th", wants: 2},
+		{name: "abbreviation", input: "U.S.A. a..b", wants: 3},
+		{name: "trailing punctuation", input: "end. end' end:", wants: 3},
+		{name: "midletter colon", input: "x:y", wants: 1},
+		{name: "midnum between letters", input: "a,b", wants: 2},
+		{name: "underscores", input: "snake_case _private __ trailing_", wants: 3},
+		{name: "ideographs", input: "日本語", wants: 3},
+		{name: "hiragana", input: "ひらがな", wants: 4},
+		{name: "katakana", input: "カタカナ テキスト", wants: 2},
+		{name: "mixed japanese", input: "日本語のテキスト", wants: 5},
+		{name: "hangul", input: "한국어 텍스트", wants: 2},
+		{name: "combining marks", input: "nai\u0308ve cafe\u0301", wants: 2},
+		{name: "soft hyphen", input: "co\u00adop", wants: 1},
+		{name: "emoji", input: "😀 👍🏽 smile", wants: 1},
+		{name: "invalid bytes", input: "a\xffb \xe2\x82", wants: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for n := 1; n <= len(tc.input)+1; n++ {
+				assert.Equal(t, tc.wants, countSplit(UnicodeWords, tc.input, n), tc.input)
+			}
+		})
+	}
+}
+
+func TestRegexWords(t *testing.T) {
+	testCases := []struct {
+		name  string
+		expr  string
+		input string
+		wants uint
+	}{
+		{name: "hyphenated words", expr: `[\p{L}\p{N}]+(?:[-'’][\p{L}\p{N}]+)*`, input: "e-mail don't, well-known\n", wants: 3},
+		{name: "empty matches are skipped", expr: `x*`, input: "axxb x\n", wants: 2},
+		{name: "matched line by line", expr: `foo\s*bar`, input: "foo\nbar foo bar\nfoobar", wants: 2},
+		{name: "no trailing line break", expr: `\w+`, input: "one\ntwo three", wants: 3},
+		{name: "blank lines", expr: `\w+`, input: "\n\none\n\n", wants: 1},
+		{name: "start of line", expr: `^\w+`, input: "one two\nthree four\n", wants: 2},
+		{name: "word boundary", expr: `\bo\w*`, input: "foo one boo on\n", wants: 2},
+		{name: "empty matches between runes", expr: `日*`, input: "a日本日日\n", wants: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := RegexWords(regexp.MustCompile(tc.expr))
+
+			for n := 1; n <= len(tc.input)+1; n++ {
+				assert.Equal(t, tc.wants, countSplit(rule, tc.input, n), tc.input)
+			}
+		})
+	}
+}
+
+func TestRegexWordsLongLine(t *testing.T) {
+	re := regexp.MustCompile(`[a-z]+`)
+	input := strings.Repeat("abc ", 10) + "\n" + strings.Repeat("abc ", 10)
+
+	testCases := []struct {
+		name    string
+		maxLine int
+		wants   uint
+	}{
+		{name: "short lines", maxLine: REGEX_MAX_LINE, wants: 20},
+		// every line is matched in pieces of 8 bytes, "abc abc "
+		{name: "pieces between words", maxLine: 8, wants: 20},
+		// pieces of 6 bytes, "abc ab" and "c abc ", cut every other word in two
+		{name: "pieces cutting words", maxLine: 6, wants: 26},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for n := 1; n <= len(input)+1; n++ {
+				rc := &regexCounter{re: re, maxLine: tc.maxLine}
+
+				for rest := input; len(rest) > 0; {
+					size := min(n, len(rest))
+					rc.Write([]byte(rest[:size]))
+					rest = rest[size:]
+				}
+
+				assert.Equal(t, tc.wants, rc.Words(), fmt.Sprint(n))
+				assert.Equal(t, true, cap(rc.line) <= 2*tc.maxLine, "line is not capped")
+			}
+		})
+	}
+}
+
+func TestWhitespaceWords(t *testing.T) {
+	for _, input := range engineInputs {
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+		for n := 1; n <= 3; n++ {
+			assert.Equal(t, wants.words, countSplit(WhitespaceWords, input, n), input)
+		}
+
+		got, err := CountWordsWith(strings.NewReader(input), nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, wants.words, got, input)
+	}
+}
+
+func TestGetCountsWithRule(t *testing.T) {
+	input := "日本語のテキスト don't\te-mail\n"
+
+	wants, _ := getCountsSinglePass(strings.NewReader(input))
+	wants.words = 8
+
+	got, err := GetCountsWithRule(strings.NewReader(input), COUNT_ALL, UnicodeWords)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, wants, got)
+
+	// only the words come from the rule
+	got, err = GetCountsWithRule(strings.NewReader(input), COUNT_WORDS, UnicodeWords)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{words: 8}, got)
+
+	got, err = GetCountsWithRule(strings.NewReader(input), COUNT_LINES, UnicodeWords)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Counts{lines: 1}, got)
+}
+
+func TestCountFileWithRule(t *testing.T) {
+	content := strings.Repeat("don't e-mail 日本語\n", 1000)
+
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal("failed to create file:", err)
+	}
+
+	wants, err := GetCountsWithRule(strings.NewReader(content), COUNT_ALL, UnicodeWords)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(6000), wants.words)
+
+	testCases := []struct {
+		name string
+		opts FileOptions
+	}{
+		{name: "streamed", opts: FileOptions{Words: UnicodeWords, ParallelThreshold: -1}},
+		{name: "not split in chunks", opts: FileOptions{Words: UnicodeWords, ParallelThreshold: 1, Chunks: 4}},
+		{name: "mapped", opts: FileOptions{Words: UnicodeWords, ParallelThreshold: 1, Chunks: 4, Mmap: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CountFileWith(context.Background(), filename, tc.opts)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, wants, got)
+		})
+	}
+}
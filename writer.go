@@ -0,0 +1,93 @@
+package counter
+
+import "bytes"
+
+// Counter counts everything written to it, so it can sit in an io.TeeReader or
+// an io.MultiWriter and count a stream as it flows by. UTF-8 sequences and
+// words split across writes are counted once. The zero value counts
+// everything with words separated by white space, the same as GetCounts. A
+// Counter is not safe for concurrent use
+type Counter struct {
+	what CountOptions
+	rule WordRule
+	s    *scanner
+	// atBreaks is set when the words counter can't be cloned, wordsAtBreak
+	// holds its words at the last line break for the snapshots
+	atBreaks     bool
+	wordsAtBreak uint
+	// final holds the counts once Finish was called
+	final *Counts
+}
+
+// NewCounter returns a counter computing only the selected counts, the rest are
+// left at zero. COUNT_STATS collects the stats of the lines as well
+func NewCounter(what CountOptions) *Counter {
+	return &Counter{what: what}
+}
+
+// NewCounterWithRule works like NewCounter finding words with the rule
+func NewCounterWithRule(what CountOptions, rule WordRule) *Counter {
+	return &Counter{what: what, rule: rule}
+}
+
+func (c *Counter) scanner() *scanner {
+	if c.s == nil {
+		c.s = newScanner(c.what, c.rule)
+		c.atBreaks = c.s.words != nil && !canCloneWords(c.s.words)
+	}
+
+	return c.s
+}
+
+// Write counts p, it never fails
+func (c *Counter) Write(p []byte) (int, error) {
+	s := c.scanner()
+
+	if c.atBreaks {
+		if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+			s.write(p[:i+1])
+			c.wordsAtBreak = s.words.Words()
+			s.write(p[i+1:])
+
+			return len(p), nil
+		}
+	}
+
+	s.write(p)
+	return len(p), nil
+}
+
+// WriteString counts s, it never fails
+func (c *Counter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
+
+// Counts returns the counts of everything written so far, as if the stream
+// ended here. Writing can go on afterwards, an incomplete UTF-8 sequence or
+// line at the end is only counted as such in the snapshot. With a word rule
+// other than the built-in ones the words of that last line are left out,
+// they're only counted once its line break is written or Finish is called
+func (c *Counter) Counts() Counts {
+	if c.final != nil {
+		return *c.final
+	}
+
+	snapshot := c.scanner().clone()
+	if c.atBreaks {
+		snapshot.words = fixedWords(c.wordsAtBreak)
+	}
+
+	return snapshot.finish()
+}
+
+// Finish returns the counts once the whole stream was written, the words of a
+// last line without a line break included whatever the rule. Nothing should be
+// written afterwards, Counts and Finish keep returning the same counts
+func (c *Counter) Finish() Counts {
+	if c.final == nil {
+		counts := c.scanner().finish()
+		c.final = &counts
+	}
+
+	return *c.final
+}
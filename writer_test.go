@@ -0,0 +1,170 @@
+package counter
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"bloom.io/github.com/FerDev12/wc-go/test/assert"
+)
+
+func TestCounter(t *testing.T) {
+	for _, input := range engineInputs {
+		wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+		for n := 1; n <= 5; n++ {
+			c := &Counter{}
+
+			for rest := input; len(rest) > 0; {
+				size := min(n, len(rest))
+
+				written, err := c.WriteString(rest[:size])
+				assert.Equal(t, nil, err)
+				assert.Equal(t, size, written)
+
+				// taking a snapshot does not change what comes next
+				c.Counts()
+				rest = rest[size:]
+			}
+
+			assert.Equal(t, wants, c.Counts(), input)
+			assert.Equal(t, wants, c.Counts(), "counted twice", input)
+		}
+	}
+}
+
+func TestCounterSnapshot(t *testing.T) {
+	c := NewCounter(COUNT_ALL)
+
+	c.Write([]byte("one tw"))
+	assert.Equal(t, Counts{words: 2, chars: 6, bytes: 6, maxLineLength: 6}, c.Counts())
+
+	c.Write([]byte("o\n\xe2\x82"))
+	// the cut sequence counts as two invalid characters for now
+	assert.Equal(t, Counts{lines: 1, words: 3, chars: 10, bytes: 10, maxLineLength: 7}, c.Counts())
+
+	c.Write([]byte("\xac\n"))
+	assert.Equal(t, Counts{lines: 2, words: 3, chars: 10, bytes: 12, maxLineLength: 7}, c.Counts())
+}
+
+func TestCounterSelected(t *testing.T) {
+	input := "one two\nthree\n"
+
+	c := NewCounter(COUNT_LINES | COUNT_STATS)
+	io.WriteString(c, input[:5])
+
+	snapshot := c.Counts()
+	assert.Equal(t, uint(0), snapshot.lines)
+	assert.Equal(t, newDistribution(5), snapshot.Stats().LineBytes)
+
+	io.WriteString(c, input[5:])
+
+	wants, err := GetCountsWith(strings.NewReader(input), COUNT_LINES|COUNT_STATS)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, wants, c.Counts())
+
+	// the snapshot is not changed by later writes
+	assert.Equal(t, newDistribution(5), snapshot.Stats().LineBytes)
+}
+
+func TestCounterInPipeline(t *testing.T) {
+	input := strings.Repeat("日本語 text flowing through\n", 10000)
+	wants, _ := getCountsSinglePass(strings.NewReader(input))
+
+	tee := &Counter{}
+	copied := strings.Builder{}
+
+	_, err := io.Copy(&copied, io.TeeReader(strings.NewReader(input), tee))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, input, copied.String())
+	assert.Equal(t, wants, tee.Counts())
+
+	a, b := &Counter{}, NewCounter(COUNT_BYTES)
+	io.Copy(io.MultiWriter(a, b), strings.NewReader(input))
+	assert.Equal(t, wants, a.Counts())
+	assert.Equal(t, Counts{bytes: wants.bytes}, b.Counts())
+}
+
+func TestCounterStatsInvalidSplit(t *testing.T) {
+	input := "a\xe2\x82b c\nd\n"
+
+	wants, err := GetCountsWith(strings.NewReader(input), COUNT_ALL|COUNT_STATS)
+	assert.Equal(t, nil, err)
+
+	for i := range len(input) {
+		c := NewCounter(COUNT_ALL | COUNT_STATS)
+		c.WriteString(input[:i])
+		c.WriteString(input[i:])
+		assert.Equal(t, wants, c.Counts(), input[:i])
+	}
+}
+
+func TestCounterWithRule(t *testing.T) {
+	rules := map[string]WordRule{
+		"unicode": UnicodeWords,
+		"regex":   RegexWords(regexp.MustCompile(`\w+`)),
+	}
+
+	for name, rule := range rules {
+		for _, input := range engineInputs {
+			wants, err := GetCountsWithRule(strings.NewReader(input), COUNT_ALL|COUNT_STATS, rule)
+			assert.Equal(t, nil, err)
+
+			for n := 1; n <= 5; n++ {
+				c := NewCounterWithRule(COUNT_ALL|COUNT_STATS, rule)
+
+				for rest := input; len(rest) > 0; {
+					size := min(n, len(rest))
+					c.WriteString(rest[:size])
+
+					// taking a snapshot does not change what comes next
+					c.Counts()
+					rest = rest[size:]
+				}
+
+				assert.Equal(t, wants, c.Counts(), name, input)
+			}
+		}
+	}
+}
+
+// opaqueRule hides the counters of the rule so they can't be cloned
+type opaqueRule struct {
+	rule WordRule
+}
+
+func (o opaqueRule) NewWordCounter() WordCounter {
+	return struct{ WordCounter }{o.rule.NewWordCounter()}
+}
+
+func TestCounterWithRuleSnapshot(t *testing.T) {
+	c := NewCounterWithRule(COUNT_WORDS|COUNT_STATS, opaqueRule{rule: UnicodeWords})
+
+	c.WriteString("don't stop\nbelie")
+	// the words of the line being written are left out
+	snapshot := c.Counts()
+	assert.Equal(t, uint(2), snapshot.words)
+	assert.Equal(t, newDistribution(2, 0), snapshot.Stats().LineWords)
+
+	c.WriteString("ving\n")
+	snapshot = c.Counts()
+	assert.Equal(t, uint(3), snapshot.words)
+	assert.Equal(t, newDistribution(2, 1), snapshot.Stats().LineWords)
+}
+
+func TestCounterFinish(t *testing.T) {
+	input := "one two\nthree four"
+	rule := opaqueRule{rule: UnicodeWords}
+
+	wants, err := GetCountsWithRule(strings.NewReader(input), COUNT_ALL|COUNT_STATS, rule)
+	assert.Equal(t, nil, err)
+
+	c := NewCounterWithRule(COUNT_ALL|COUNT_STATS, rule)
+	c.WriteString(input)
+	assert.Equal(t, uint(2), c.Counts().words)
+
+	assert.Equal(t, wants, c.Finish())
+	assert.Equal(t, uint(4), c.Finish().words)
+	assert.Equal(t, wants, c.Counts(), "counts after finishing")
+}